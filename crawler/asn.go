@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ASNDB maps addresses to the AS number announcing them, from an offline
+// database (-asn-db).
+type ASNDB struct {
+	// ranges are sorted by start and don't overlap.
+	ranges []asnRange
+}
+
+// asnRange is an inclusive range of addresses in 16 byte form.
+type asnRange struct {
+	start, end net.IP
+	asn        uint32
+}
+
+// LoadASNDB reads an ip2asn database: tab separated lines of first address,
+// last address, AS number and optional further columns, as published by
+// iptoasn.com. Files ending in .gz are decompressed. Ranges with AS number
+// 0 are not routed and skipped.
+//
+//	1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET
+func LoadASNDB(filename string) (*ASNDB, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	db := &ASNDB{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s:%d: expected first address, last address and AS number", filename, lineNo)
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		asn, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "AS"), 10, 32)
+		if start == nil || end == nil || err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid range", filename, lineNo)
+		}
+		if asn != 0 {
+			db.ranges = append(db.ranges, asnRange{start: start.To16(), end: end.To16(), asn: uint32(asn)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	return db, nil
+}
+
+// Lookup returns the AS number announcing ip, if the database has it.
+func (db *ASNDB) Lookup(ip net.IP) (uint32, bool) {
+	ip = ip.To16()
+	if ip == nil {
+		return 0, false
+	}
+	// The last range starting at or before ip
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, db.ranges[i].end) > 0 {
+		return 0, false
+	}
+	return db.ranges[i].asn, true
+}
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultTrackingParams are the query parameters dropped by
+// CanonicalRules.DropParams by default. A trailing * matches a prefix.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gclsrc", "msclkid", "yclid", "igshid",
+	"mc_cid", "mc_eid", "_ga", "_gl", "_hsenc", "_hsmi",
+}
+
+// CanonicalRules select how URLs are normalized before deduplication
+// (-canon). Scheme and host are always lower cased.
+type CanonicalRules struct {
+	// SortParams makes ?b=2&a=1 equal to ?a=1&b=2.
+	SortParams bool
+	// TrailingSlash makes /a/ equal to /a.
+	TrailingSlash bool
+	// Fragment drops #fragments.
+	Fragment bool
+	// DefaultPort drops :80 from http and :443 from https URLs.
+	DefaultPort bool
+	// DropParams are removed from the query. A trailing * matches a prefix.
+	DropParams []string
+	// IgnoreValues makes URLs with the same path and parameter names equal
+	// whatever the values, so ?id=1 and ?id=2 are duplicates.
+	IgnoreValues bool
+}
+
+// canonicalRuleNames are the names accepted by ParseCanonicalRules.
+var canonicalRuleNames = []string{"sort", "slash", "fragment", "port", "tracking", "values"}
+
+// DefaultCanonicalRules returns every rule except IgnoreValues.
+func DefaultCanonicalRules() CanonicalRules {
+	return CanonicalRules{
+		SortParams:    true,
+		TrailingSlash: true,
+		Fragment:      true,
+		DefaultPort:   true,
+		DropParams:    DefaultTrackingParams,
+	}
+}
+
+// ParseCanonicalRules parses a comma separated list of rule names: sort,
+// slash, fragment, port, tracking and values, or "none".
+func ParseCanonicalRules(spec string) (CanonicalRules, error) {
+	var rules CanonicalRules
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(name) {
+		case "", "none":
+		case "sort":
+			rules.SortParams = true
+		case "slash":
+			rules.TrailingSlash = true
+		case "fragment":
+			rules.Fragment = true
+		case "port":
+			rules.DefaultPort = true
+		case "tracking":
+			rules.DropParams = append(rules.DropParams, DefaultTrackingParams...)
+		case "values":
+			rules.IgnoreValues = true
+		default:
+			return rules, fmt.Errorf("unknown canonicalization rule %q (want %s or none)", name, strings.Join(canonicalRuleNames, ", "))
+		}
+	}
+	return rules, nil
+}
+
+// dropParam reports whether the query parameter name is in DropParams.
+func (r CanonicalRules) dropParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range r.DropParams {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, strings.ToLower(prefix)) {
+				return true
+			}
+		} else if strings.EqualFold(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonical returns the canonical form of rawURL, used as its
+// deduplication key. URLs that can't be parsed are returned unchanged.
+func (r CanonicalRules) Canonical(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if r.DefaultPort {
+		if port := u.Port(); port == "80" && u.Scheme == "http" || port == "443" && u.Scheme == "https" {
+			u.Host = strings.TrimSuffix(u.Host, ":"+port)
+		}
+	}
+	if u.Host != "" && u.Path == "" {
+		u.Path = "/"
+	}
+	if r.TrailingSlash && len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.RawPath != "" {
+			u.RawPath = strings.TrimRight(u.RawPath, "/")
+		}
+		if u.Path == "" {
+			u.Path, u.RawPath = "/", ""
+		}
+	}
+	if r.Fragment {
+		u.Fragment, u.RawFragment = "", ""
+	}
+
+	if u.RawQuery != "" {
+		var pairs []string
+		seen := make(map[string]bool)
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			if pair == "" {
+				continue
+			}
+			name, _, _ := strings.Cut(pair, "=")
+			if decoded, err := url.QueryUnescape(name); err == nil {
+				name = decoded
+			}
+			if r.dropParam(name) {
+				continue
+			}
+			if r.IgnoreValues {
+				pair = url.QueryEscape(name)
+			}
+			if r.IgnoreValues && seen[pair] {
+				continue
+			}
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+		if r.SortParams {
+			sort.Strings(pairs)
+		}
+		u.RawQuery = strings.Join(pairs, "&")
+	}
+	// "/a?" is "/a"
+	u.ForceQuery = false
+	return u.String()
+}
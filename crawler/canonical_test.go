@@ -0,0 +1,69 @@
+package crawler
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	all := DefaultCanonicalRules()
+	tests := []struct {
+		name  string
+		rules CanonicalRules
+		in    string
+		want  string
+	}{
+		{"lower case scheme and host", CanonicalRules{}, "HTTPS://Target.COM/Path", "https://target.com/Path"},
+		{"empty path", CanonicalRules{}, "https://target.com", "https://target.com/"},
+		{"unparsable", all, "http://[::1", "http://[::1"},
+
+		{"sort params", CanonicalRules{SortParams: true}, "https://t.com/?b=2&a=1&a=0", "https://t.com/?a=0&a=1&b=2"},
+		{"params kept in order", CanonicalRules{}, "https://t.com/?b=2&a=1", "https://t.com/?b=2&a=1"},
+		{"empty pairs", CanonicalRules{}, "https://t.com/?a=1&&b=2&", "https://t.com/?a=1&b=2"},
+		{"empty query", all, "https://t.com/a?", "https://t.com/a"},
+
+		{"trailing slash", CanonicalRules{TrailingSlash: true}, "https://t.com/a/b//", "https://t.com/a/b"},
+		{"root slash kept", CanonicalRules{TrailingSlash: true}, "https://t.com/", "https://t.com/"},
+		{"only slashes", CanonicalRules{TrailingSlash: true}, "https://t.com//", "https://t.com/"},
+		{"escaped path", CanonicalRules{TrailingSlash: true}, "https://t.com/a%2Fb/", "https://t.com/a%2Fb"},
+		{"trailing slash kept", CanonicalRules{}, "https://t.com/a/", "https://t.com/a/"},
+
+		{"http default port", CanonicalRules{DefaultPort: true}, "http://t.com:80/a", "http://t.com/a"},
+		{"https default port", CanonicalRules{DefaultPort: true}, "https://T.com:443/a", "https://t.com/a"},
+		{"other scheme's port", CanonicalRules{DefaultPort: true}, "https://t.com:80/a", "https://t.com:80/a"},
+		{"other port", CanonicalRules{DefaultPort: true}, "http://t.com:8080/a", "http://t.com:8080/a"},
+		{"ipv6 default port", CanonicalRules{DefaultPort: true}, "http://[::1]:80/a", "http://[::1]/a"},
+
+		{"fragment", CanonicalRules{Fragment: true}, "https://t.com/a#top", "https://t.com/a"},
+		{"fragment kept", CanonicalRules{}, "https://t.com/a#top", "https://t.com/a#top"},
+
+		{"tracking params", all, "https://t.com/a?utm_source=x&id=1&fbclid=y&UTM_Medium=z", "https://t.com/a?id=1"},
+		{"escaped tracking param", all, "https://t.com/a?utm%5Fsource=x&id=1", "https://t.com/a?id=1"},
+		{"only tracking params", all, "https://t.com/a?gclid=1", "https://t.com/a"},
+		{"prefix only with star", CanonicalRules{DropParams: []string{"ref"}}, "https://t.com/?ref=1&referrer=2", "https://t.com/?referrer=2"},
+
+		{"ignore values", CanonicalRules{IgnoreValues: true, SortParams: true}, "https://t.com/a?id=2&q=x&id=3", "https://t.com/a?id&q"},
+
+		{"all rules", all, "HTTP://T.com:80/a/?b=2&utm_campaign=c&a=1#x", "http://t.com/a?a=1&b=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Canonical(tt.in); got != tt.want {
+				t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCanonicalRules(t *testing.T) {
+	rules, err := ParseCanonicalRules("sort, slash,fragment,port,tracking,values")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rules.SortParams || !rules.TrailingSlash || !rules.Fragment || !rules.DefaultPort || !rules.IgnoreValues || len(rules.DropParams) != len(DefaultTrackingParams) {
+		t.Errorf("ParseCanonicalRules enabled %+v, want every rule", rules)
+	}
+	if rules, err := ParseCanonicalRules("none"); err != nil || rules.SortParams || rules.DropParams != nil {
+		t.Errorf(`ParseCanonicalRules("none") = %+v, %v, want no rules`, rules, err)
+	}
+	if _, err := ParseCanonicalRules("sort,bogus"); err == nil {
+		t.Error("ParseCanonicalRules accepted an unknown rule")
+	}
+}
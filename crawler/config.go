@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/xerocorps/paxkk/dedup"
+)
+
+// DefaultUserAgent is sent with every request unless Config.UserAgent is set.
+const DefaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:78.0) Gecko/20100101 Firefox/78.0"
+
+// Config controls a crawl. Every field corresponds to one of the paxkk
+// command line flags; DefaultConfig returns the same defaults the CLI uses.
+type Config struct {
+	// Depth is the maximum crawl depth (-d).
+	Depth int
+	// Threads is the per-seed request parallelism (-t).
+	Threads int
+	// SeedWorkers is the number of seeds crawled concurrently (-seed-workers).
+	SeedWorkers int
+	// MaxSize is the page size limit in KB, -1 for no limit (-size).
+	MaxSize int
+	// Insecure disables TLS verification (-insecure).
+	Insecure bool
+	// Subs restricts the crawl of each seed to its host and subdomains
+	// (-subs).
+	Subs bool
+	// Inside restricts the crawl of each seed to URLs under the seed URL
+	// (-i).
+	Inside bool
+	// Scope restricts the URLs crawled, on top of Subs and Inside. Nil
+	// allows every URL (-scope).
+	Scope *Scope
+	// PrintScope, when set, drops results outside of it. It is independent
+	// of Scope, so URLs can be reported without being crawled or the other
+	// way around (-print-scope).
+	PrintScope *Scope
+	// IPPolicy skips seeds whose host resolves to a denied address. Nil
+	// allows every address (-deny-preset, -deny-cidr-file, -allow-cidr-file,
+	// -deny-asn).
+	IPPolicy *IPPolicy
+	// Report selects the results sent by Run according to Result.InScope,
+	// "" for all of them (-report).
+	Report ScopeReport
+	// Proxy is an optional proxy URL, e.g. http://127.0.0.1:8080 (-proxy).
+	Proxy string
+	// Timeout is the maximum time spent on a single seed, 0 for none (-timeout).
+	Timeout time.Duration
+	// MaxTime is the maximum duration of the whole run, 0 for none (-max-time).
+	MaxTime time.Duration
+	// DisableRedirects stops the collector following HTTP redirects (-dr).
+	DisableRedirects bool
+	// JSFiles fetches external scripts at any depth and reports the
+	// endpoints they contain (-js-files).
+	JSFiles bool
+	// JSRelative also extracts relative paths and API routes from
+	// JavaScript, LinkFinder-style, with a confidence score (-js-relative).
+	JSRelative bool
+	// SourceMaps fetches the source maps referenced by JavaScript files and
+	// extracts endpoints from the original sources (-sourcemaps).
+	SourceMaps bool
+	// Discover fetches robots.txt and sitemaps of each seed host and reports
+	// their entries (-discover).
+	Discover bool
+	// DiscoverCrawl also crawls the URLs found by Discover (-discover-crawl).
+	DiscoverCrawl bool
+	// RespectRobots makes the collectors obey robots.txt (-respect-robots).
+	RespectRobots bool
+	// FetchStatus requests every discovered URL in scope to fill
+	// Result.URLStatus (-fetch-status).
+	FetchStatus bool
+	// Params collects query and form parameter names per endpoint for
+	// Crawler.Endpoints (-params), from every URL found, including the
+	// ones Report and PrintScope leave out.
+	Params bool
+	// SubmitForms fills forms with dummy values and crawls the responses.
+	// Only GET forms are submitted unless AllowPost is set (-submit-forms).
+	SubmitForms bool
+	// AllowPost lets SubmitForms send POST forms (-allow-post).
+	AllowPost bool
+	// FormValues are the values SubmitForms uses, by field name, instead of
+	// the defaults for the input type (-form-values).
+	FormValues map[string]string
+	// Keywords, when non-empty, only reports URLs containing one of them (-k).
+	Keywords []string
+	// Patterns are extra named regexes matched against the text of every
+	// element (-pattern-file).
+	Patterns []Pattern
+	// SecretRules, when non-empty, scan every text response for secrets
+	// (-secrets, -secret-rules).
+	SecretRules []SecretRule
+	// OnFinding receives the secrets found by SecretRules. It is called
+	// concurrently from the crawl goroutines.
+	OnFinding func(Finding)
+	// Headers are set on every request (-h, -H-file).
+	Headers map[string]string
+	// HeaderRules set extra headers on requests to matching hosts (-H-file).
+	HeaderRules []HeaderRule
+	// Jar, when set, is shared by the collectors of every seed, so a session
+	// carries across the whole run (-cookies, -cookie-jar).
+	Jar http.CookieJar
+	// Cookies are added to the jar for each seed's host (-cookie).
+	Cookies []*http.Cookie
+	// Login, when set, is performed before crawling and again whenever a
+	// response matches its re-login condition (-login).
+	Login *LoginSpec
+	// Visited, when set, holds the visited requests of every seed's
+	// collector instead of colly's in-memory set (-dedup).
+	Visited dedup.Store
+	// State, when set, records completed seeds and the pending frontier so
+	// an interrupted crawl can be resumed. Visited should then be a
+	// persistent store as well (-state, -resume).
+	State *State
+	// UserAgent overrides DefaultUserAgent.
+	UserAgent string
+}
+
+// DefaultConfig returns the configuration used by the paxkk CLI when no
+// flags are given.
+func DefaultConfig() Config {
+	return Config{
+		Depth:       2,
+		Threads:     8,
+		SeedWorkers: 1,
+		MaxSize:     -1,
+		Scope:       DefaultScope(),
+		IPPolicy:    DefaultIPPolicy(),
+		JSFiles:     true,
+		SourceMaps:  true,
+		UserAgent:   DefaultUserAgent,
+	}
+}
@@ -0,0 +1,243 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Jar is an http.CookieJar that can be loaded from and saved to Netscape
+// cookies.txt files, so a session can be shared by every seed of a run and
+// carried over to the next run.
+type Jar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	entries map[string]jarEntry
+}
+
+// jarEntry is a cookie as stored for Save.
+type jarEntry struct {
+	domain   string
+	hostOnly bool
+	cookie   http.Cookie
+	expires  time.Time
+}
+
+// NewJar returns an empty Jar.
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(nil)
+	return &Jar{jar: jar, entries: make(map[string]jarEntry)}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		e := jarEntry{cookie: *c}
+		if c.Domain != "" {
+			e.domain = strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		} else {
+			e.domain = strings.ToLower(u.Hostname())
+			e.hostOnly = true
+		}
+		if e.cookie.Path == "" || !strings.HasPrefix(e.cookie.Path, "/") {
+			e.cookie.Path = defaultCookiePath(u)
+		}
+		key := e.domain + ";" + e.cookie.Path + ";" + c.Name
+
+		switch {
+		case c.MaxAge < 0:
+			delete(j.entries, key)
+			continue
+		case c.MaxAge > 0:
+			e.expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			if !c.Expires.After(now) {
+				delete(j.entries, key)
+				continue
+			}
+			e.expires = c.Expires
+		}
+		j.entries[key] = e
+	}
+}
+
+// defaultCookiePath is the RFC 6265 default-path of u.
+func defaultCookiePath(u *url.URL) string {
+	dir := path.Dir(u.Path)
+	if !strings.HasPrefix(dir, "/") {
+		return "/"
+	}
+	return dir
+}
+
+// LoadFile adds the cookies of a Netscape cookies.txt file, as exported by
+// browsers and curl, to the jar. Expired cookies are skipped.
+func (j *Jar) LoadFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("%s:%d: expected 7 tab separated fields, got %d", filename, lineNo, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid expiry %q", filename, lineNo, fields[4])
+		}
+
+		domain := strings.TrimPrefix(fields[0], ".")
+		secure := strings.EqualFold(fields[3], "TRUE")
+		c := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(fields[1], "TRUE") {
+			c.Domain = domain
+		}
+		if expiry != 0 {
+			c.Expires = time.Unix(expiry, 0)
+			if !c.Expires.After(now) {
+				continue
+			}
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		j.SetCookies(&url.URL{Scheme: scheme, Host: domain, Path: c.Path}, []*http.Cookie{c})
+	}
+	return scanner.Err()
+}
+
+// Save writes every unexpired cookie in the jar to filename in Netscape
+// cookies.txt format. Session cookies are saved with an expiry of 0.
+// Cookies the jar rejected, such as ones set for another domain, are not
+// saved.
+func (j *Jar) Save(filename string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	now := time.Now()
+	keys := make([]string, 0, len(j.entries))
+	for key := range j.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sent := make(map[string][]*http.Cookie)
+	for _, key := range keys {
+		e := j.entries[key]
+		if !e.expires.IsZero() && !e.expires.After(now) {
+			continue
+		}
+		if !j.holds(e, sent) {
+			continue
+		}
+		domain, includeSubdomains := e.domain, "FALSE"
+		if !e.hostOnly {
+			domain, includeSubdomains = "."+e.domain, "TRUE"
+		}
+		if e.cookie.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		secure := "FALSE"
+		if e.cookie.Secure {
+			secure = "TRUE"
+		}
+		var expiry int64
+		if !e.expires.IsZero() {
+			expiry = e.expires.Unix()
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, includeSubdomains, e.cookie.Path, secure, expiry, e.cookie.Name, e.cookie.Value)
+	}
+
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// holds reports whether the underlying jar stored e, by checking that it
+// sends the cookie back to the cookie's own domain and path. sent caches
+// the cookies of each origin.
+func (j *Jar) holds(e jarEntry, sent map[string][]*http.Cookie) bool {
+	// The jar ignores ports; one is given so IPv6 hosts are looked up
+	// the way requests are
+	u := &url.URL{Scheme: "https", Host: net.JoinHostPort(e.domain, "443"), Path: e.cookie.Path}
+	cookies, ok := sent[u.String()]
+	if !ok {
+		cookies = j.jar.Cookies(u)
+		sent[u.String()] = cookies
+	}
+	for _, c := range cookies {
+		if c.Name == e.cookie.Name && c.Value == e.cookie.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCookies parses a Cookie header style string such as "k=v; k2=v2".
+func ParseCookies(raw string) []*http.Cookie {
+	header := http.Header{"Cookie": []string{raw}}
+	return (&http.Request{Header: header}).Cookies()
+}
+
+// collectorJar is the cookie jar of a collector without Config.Jar, for
+// requests sent beside it.
+type collectorJar struct {
+	c *colly.Collector
+}
+
+func (j collectorJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.c.SetCookies(u.String(), cookies)
+}
+
+func (j collectorJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.c.Cookies(u.String())
+}
@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// savedCookies saves j and returns the cookie lines of the file.
+func savedCookies(t *testing.T, j *Jar) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "saved.txt")
+	if err := j.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if lines[0] != "# Netscape HTTP Cookie File" {
+		t.Errorf("header = %q", lines[0])
+	}
+	return lines[1:]
+}
+
+func TestJarLoadSaveRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			"host only", "example.com\tFALSE\t/\tFALSE\t4102444800\tsid\tabc",
+			[]string{"example.com\tFALSE\t/\tFALSE\t4102444800\tsid\tabc"},
+		},
+		{
+			"subdomains", ".example.com\tTRUE\t/app\tFALSE\t4102444800\tsid\tabc",
+			[]string{".example.com\tTRUE\t/app\tFALSE\t4102444800\tsid\tabc"},
+		},
+		{
+			"secure", "example.com\tFALSE\t/\tTRUE\t4102444800\tsid\tabc",
+			[]string{"example.com\tFALSE\t/\tTRUE\t4102444800\tsid\tabc"},
+		},
+		{
+			"http only", "#HttpOnly_.example.com\tTRUE\t/\tFALSE\t4102444800\tsid\tabc",
+			[]string{"#HttpOnly_.example.com\tTRUE\t/\tFALSE\t4102444800\tsid\tabc"},
+		},
+		{
+			"session", "example.com\tFALSE\t/\tFALSE\t0\tsid\tabc",
+			[]string{"example.com\tFALSE\t/\tFALSE\t0\tsid\tabc"},
+		},
+		{
+			"expired", "example.com\tFALSE\t/\tFALSE\t1000\tsid\tabc",
+			nil,
+		},
+		{
+			"comments and blank lines", "# Netscape HTTP Cookie File\n\n# example.com\tFALSE\t/\tFALSE\t0\told\tx\n" +
+				"example.com\tFALSE\t/\tFALSE\t0\tsid\tabc\n",
+			[]string{"example.com\tFALSE\t/\tFALSE\t0\tsid\tabc"},
+		},
+		{
+			"sorted", "b.example.com\tFALSE\t/\tFALSE\t0\tsid\tb\na.example.com\tFALSE\t/\tFALSE\t0\tsid\ta",
+			[]string{"a.example.com\tFALSE\t/\tFALSE\t0\tsid\ta", "b.example.com\tFALSE\t/\tFALSE\t0\tsid\tb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.txt")
+			if err := os.WriteFile(path, []byte(tt.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+			j := NewJar()
+			if err := j.LoadFile(path); err != nil {
+				t.Fatal(err)
+			}
+			got := savedCookies(t, j)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("saved %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJarLoadFileSendsCookies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	content := "#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tsid\tabc\n" +
+		"example.com\tFALSE\t/admin\tTRUE\t0\tadmin\tyes\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	j := NewJar()
+	if err := j.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://www.example.com/", "sid=abc"},
+		{"http://example.com/admin/users", "sid=abc"},
+		{"https://example.com/admin/users", "admin=yes sid=abc"},
+		{"https://other.com/", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		var names []string
+		for _, c := range j.Cookies(u) {
+			names = append(names, c.Name+"="+c.Value)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("Cookies(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestJarLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"too few fields", "example.com\tFALSE\t/\tFALSE\t0\tsid", "cookies.txt:1: expected 7 tab separated fields, got 6"},
+		{"spaces", "example.com FALSE / FALSE 0 sid abc", "cookies.txt:1: expected 7 tab separated fields, got 1"},
+		{"invalid expiry", "# header\nexample.com\tFALSE\t/\tFALSE\tnever\tsid\tabc", `cookies.txt:2: invalid expiry "never"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.txt")
+			if err := os.WriteFile(path, []byte(tt.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+			err := NewJar().LoadFile(path)
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("LoadFile error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJarSavesOnlyAcceptedCookies(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		cookie http.Cookie
+		want   []string
+	}{
+		{
+			"host only", "http://site.test/a/b",
+			http.Cookie{Name: "sid", Value: "1"},
+			[]string{"site.test\tFALSE\t/a\tFALSE\t0\tsid\t1"},
+		},
+		{
+			"own domain", "http://www.site.test/",
+			http.Cookie{Name: "sid", Value: "1", Domain: "site.test", Path: "/"},
+			[]string{".site.test\tTRUE\t/\tFALSE\t0\tsid\t1"},
+		},
+		{
+			"other domain", "http://site.test/",
+			http.Cookie{Name: "sid", Value: "1", Domain: "evil.test", Path: "/"},
+			nil,
+		},
+		{
+			"subdomain of host", "http://site.test/",
+			http.Cookie{Name: "sid", Value: "1", Domain: "www.site.test", Path: "/"},
+			nil,
+		},
+		{
+			"domain on IP host", "http://127.0.0.1/",
+			http.Cookie{Name: "sid", Value: "1", Domain: "127.0.0.2", Path: "/"},
+			nil,
+		},
+		{
+			"expired", "http://site.test/",
+			http.Cookie{Name: "sid", Value: "1", Path: "/", Expires: time.Unix(1000, 0)},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			j := NewJar()
+			cookie := tt.cookie
+			j.SetCookies(u, []*http.Cookie{&cookie})
+			got := savedCookies(t, j)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("saved %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
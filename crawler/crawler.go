@@ -0,0 +1,517 @@
+// Package crawler implements the paxkk web crawler. It can be embedded in
+// other Go programs; the paxkk command is a thin wrapper around it.
+//
+//	cr, err := crawler.New(crawler.DefaultConfig())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for res := range cr.Run(ctx, seeds) {
+//		fmt.Println(res.URL)
+//	}
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Crawler crawls seed URLs and reports every URL it discovers.
+type Crawler struct {
+	cfg       Config
+	transport *http.Transport
+	stats     stats
+	login     loginSession
+	params    paramIndex
+	// denied maps the seed hosts denied by Config.IPPolicy to the reason.
+	denied sync.Map
+}
+
+// seedCrawl is the state shared by the callbacks of one seed's collector.
+type seedCrawl struct {
+	ctx     context.Context
+	seed    string
+	results chan<- Result
+	// statuses caches the URL statuses of Config.FetchStatus by
+	// canonical URL, from the collector's responses and from probes sent with
+	// client. probes bounds the concurrent probes and pending tracks the
+	// results waiting for theirs.
+	statuses sync.Map
+	client   *http.Client
+	probes   chan struct{}
+	pending  sync.WaitGroup
+	// secrets dedups the findings of Config.SecretRules.
+	secrets sync.Map
+	// submitted dedups the forms sent by Config.SubmitForms.
+	submitted sync.Map
+	// visited is the collector's storage when Config.Visited is set.
+	visited *visitedStorage
+	// scope holds the rules of Config.Subs and Config.Inside for the seed.
+	scope *Scope
+}
+
+// New validates cfg and returns a Crawler ready to Run.
+func New(cfg Config) (*Crawler, error) {
+	if cfg.Threads < 1 {
+		return nil, errors.New("threads must be at least 1")
+	}
+	if cfg.SeedWorkers < 1 {
+		return nil, errors.New("seed workers must be at least 1")
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+
+	if (len(cfg.Cookies) != 0 || cfg.Login != nil) && cfg.Jar == nil {
+		cfg.Jar = NewJar()
+	}
+
+	// Skip TLS verification if -insecure flag is present
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Insecure},
+	}
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &Crawler{cfg: cfg, transport: transport}, nil
+}
+
+// Run crawls the seeds received on seeds and sends the discovered URLs on
+// the returned channel. Up to Config.SeedWorkers seeds are crawled at once,
+// each with its own collector. The channel is closed once seeds is closed
+// and drained, or ctx is done and every in-flight request has been stopped.
+// Config.MaxTime bounds the whole run.
+func (cr *Crawler) Run(ctx context.Context, seeds <-chan string) <-chan Result {
+	results := make(chan Result, cr.cfg.Threads*cr.cfg.SeedWorkers)
+	go func() {
+		defer close(results)
+		if cr.cfg.MaxTime > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cr.cfg.MaxTime)
+			defer cancel()
+		}
+
+		if err := cr.ensureLogin(ctx); err != nil {
+			log.Println("[login]", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < cr.cfg.SeedWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cr.seedWorker(ctx, seeds, results)
+			}()
+		}
+		wg.Wait()
+	}()
+	return results
+}
+
+// seedWorker crawls seeds one at a time until seeds is closed or ctx is done.
+func (cr *Crawler) seedWorker(ctx context.Context, seeds <-chan string, results chan<- Result) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case seed, ok := <-seeds:
+			if !ok {
+				return
+			}
+			cr.stats.seeds.Add(1)
+			cr.crawlSeed(ctx, seed, results)
+		}
+	}
+}
+
+// Stats returns a snapshot of the crawl counters.
+func (cr *Crawler) Stats() Stats {
+	return cr.stats.snapshot()
+}
+
+// crawlSeed crawls a single seed URL until it is exhausted, Config.Timeout
+// expires or ctx is done.
+func (cr *Crawler) crawlSeed(ctx context.Context, seed string, results chan<- Result) {
+	if cr.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cr.cfg.Timeout)
+		defer cancel()
+	}
+
+	if cr.cfg.State != nil && cr.cfg.State.completed(seed) {
+		log.Println("[resume] skipping completed seed " + seed)
+		cr.stats.previous.Add(1)
+		return
+	}
+
+	s := &seedCrawl{ctx: ctx, seed: seed, results: results}
+	c, err := cr.newCollector(s)
+	if err != nil {
+		log.Println("Error parsing URL:", err)
+		cr.stats.skipped.Add(1)
+		return
+	}
+	if seedURL, _ := url.Parse(seed); !cr.inScope(s, seedURL) {
+		log.Println("[out of scope] " + seed)
+		cr.stats.skipped.Add(1)
+		return
+	}
+	if !cr.allowSeed(ctx, seed) {
+		return
+	}
+
+	if len(cr.cfg.Cookies) != 0 {
+		if u, err := url.Parse(seed); err == nil {
+			cr.cfg.Jar.SetCookies(u, cr.cfg.Cookies)
+		}
+	}
+
+	// Check if URL is alive before scraping
+	if !cr.isURLAlive(ctx, seed) {
+		if ctx.Err() == nil {
+			log.Println("[URL not reachable] " + seed)
+			cr.stats.skipped.Add(1)
+			return
+		}
+	} else {
+		var pending map[string]json.RawMessage
+		if cr.cfg.State != nil {
+			pending = cr.cfg.State.takePending(seed)
+		}
+		if len(pending) != 0 {
+			// Continue where the previous run stopped
+			cr.resumeFrontier(s, c, pending)
+		} else {
+			// Start scraping
+			c.Visit(seed)
+			if cr.cfg.Discover {
+				cr.discover(s, c)
+			}
+		}
+		// Wait until threads are finished, or aborted by ctx
+		c.Wait()
+		s.pending.Wait()
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) && cr.cfg.Timeout > 0:
+		log.Println("[timeout] " + seed)
+		cr.stats.timedOut.Add(1)
+	case ctx.Err() != nil:
+		cr.stats.cancelled.Add(1)
+	default:
+		cr.stats.completed.Add(1)
+		if cr.cfg.State != nil {
+			cr.cfg.State.markCompleted(seed)
+		}
+	}
+}
+
+// newCollector builds the colly collector for one seed and registers the
+// extraction callbacks on it.
+func (cr *Crawler) newCollector(s *seedCrawl) (*colly.Collector, error) {
+	cfg := cr.cfg
+	hostname, err := extractHostname(s.seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Instantiate default collector
+	c := colly.NewCollector(
+		// default user agent header
+		colly.UserAgent(cfg.UserAgent),
+		// set MaxDepth to the specified depth
+		colly.MaxDepth(cfg.Depth),
+		// specify Async for threading
+		colly.Async(true),
+	)
+	c.IgnoreRobotsTxt = !cfg.RespectRobots
+
+	// set a page size limit
+	if cfg.MaxSize != -1 {
+		c.MaxBodySize = cfg.MaxSize * 1024
+	}
+
+	// -i keeps the crawl under the seed URL, -subs on the seed's domain
+	switch {
+	case cfg.Inside:
+		s.scope = &Scope{include: []scopeRule{{prefix: s.seed}}}
+	case cfg.Subs:
+		s.scope = &Scope{include: []scopeRule{{host: strings.ToLower(hostname), wildcard: true}}}
+	}
+
+	// Redirects out of scope are not followed; the redirect response is
+	// scraped instead. Setting a handler replaces colly's default one, so
+	// its limit and Authorization stripping are kept here.
+	c.SetRedirectHandler(func(req *http.Request, via []*http.Request) error {
+		// If `-dr` flag provided, do not follow HTTP redirects.
+		if cfg.DisableRedirects || len(via) >= 10 || !cr.inScope(s, req.URL) {
+			return http.ErrUseLastResponse
+		}
+		if req.URL.Host != via[len(via)-1].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	})
+	// Set parallelism
+	c.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: cfg.Threads})
+
+	// Stop issuing requests once the seed is cancelled, and never request
+	// URLs out of scope
+	c.OnRequest(func(r *colly.Request) {
+		if s.ctx.Err() != nil || !cr.inScope(s, r.URL) {
+			r.Abort()
+			return
+		}
+		cr.stats.requests.Add(1)
+	})
+
+	// Scripts are requested by the JS file extractor before the generic
+	// extractors visit them, so they carry the page that loaded them
+	if cfg.JSFiles {
+		cr.registerJSFileExtractors(c, s)
+	}
+	cr.registerExtractors(c, s)
+	cr.registerCSSExtractors(c, s)
+	cr.registerFormExtractor(c, s)
+	if cfg.JSRelative {
+		cr.registerJSRelativeExtractors(c, s)
+	}
+	if cfg.SourceMaps {
+		cr.registerSourceMapExtractors(c, s)
+	}
+	if cfg.Login != nil {
+		cr.registerRelogin(c, s)
+	}
+	if len(cfg.SecretRules) != 0 {
+		cr.registerSecretScanner(c, s)
+	}
+	if cfg.State != nil {
+		cr.registerFrontier(c, s)
+	}
+
+	// add the custom headers
+	if len(cfg.Headers) != 0 || len(cfg.HeaderRules) != 0 {
+		c.OnRequest(cr.setHeaders)
+	}
+
+	c.WithTransport(&contextTransport{ctx: s.ctx, base: cr.transport})
+	if cfg.FetchStatus {
+		cr.registerStatusRecorder(c, s)
+	}
+
+	// The storage replaces the client's jar, so it goes first
+	if cfg.Visited != nil {
+		s.visited = newVisitedStorage(cfg.Visited, s.seed)
+		if err := c.SetStorage(s.visited); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Jar != nil {
+		c.SetCookieJar(cfg.Jar)
+	}
+
+	return c, nil
+}
+
+// inScope reports whether u may be requested while crawling s: it must be
+// allowed by Config.Scope and by the seed's -subs or -i rules.
+func (cr *Crawler) inScope(s *seedCrawl, u *url.URL) bool {
+	return cr.cfg.Scope.allows(s.ctx, u) && s.scope.allows(s.ctx, u)
+}
+
+// emit resolves link against the page e was found on and sends it to
+// the seed's results, unless it is filtered out by Config.Keywords. attr
+// names the attribute link was read from, if any.
+func (cr *Crawler) emit(s *seedCrawl, e *colly.HTMLElement, link string, attr string, source string) {
+	cr.emitFrom(s, e.Request, e.Response, link, Result{Source: source, Tag: e.Name, Attribute: attr})
+}
+
+// emitFrom is emit for URLs found outside of an HTML element, such as in a
+// stylesheet or script body. res carries the Source and any optional
+// fields; the URL and page fields are filled in from link, req and resp.
+func (cr *Crawler) emitFrom(s *seedCrawl, req *colly.Request, resp *colly.Response, link string, res Result) {
+	// Check if keywords are provided and if any of them are present in the URL
+	if len(cr.cfg.Keywords) != 0 && !containsKeyword(link, cr.cfg.Keywords) {
+		return
+	}
+	res.URL = req.AbsoluteURL(link)
+	if res.URL == "" {
+		return
+	}
+
+	res.Where = req.URL.String()
+	res.Seed = s.seed
+	res.Depth = resultDepth(req)
+	res.Status = resp.StatusCode
+	res.ContentType = resp.Headers.Get("Content-Type")
+	cr.send(s, res)
+}
+
+// send stamps res and delivers it to the seed's results, giving up if the
+// seed is cancelled.
+func (cr *Crawler) send(s *seedCrawl, res Result) {
+	// Parameters are collected from every URL found, whether or not it is
+	// reported
+	if cr.cfg.Params {
+		cr.params.addURL(res.URL)
+	}
+	if !cr.cfg.PrintScope.AllowsURL(res.URL) {
+		return
+	}
+	if u, err := url.Parse(res.URL); err == nil {
+		res.InScope = cr.inScope(s, u)
+	}
+	if !cr.cfg.Report.includes(res) {
+		return
+	}
+	res.Timestamp = time.Now().UTC()
+	if cr.cfg.FetchStatus && res.InScope {
+		// The status is looked up off the callback path, so extraction
+		// isn't serialized behind the probes
+		select {
+		case s.probes <- struct{}{}:
+		case <-s.ctx.Done():
+			return
+		}
+		s.pending.Add(1)
+		go func() {
+			defer s.pending.Done()
+			res.URLStatus = cr.urlStatus(s, res.URL)
+			<-s.probes
+			cr.deliver(s, res)
+		}()
+		return
+	}
+	cr.deliver(s, res)
+}
+
+// deliver sends res on the seed's results, giving up if the seed is
+// cancelled.
+func (cr *Crawler) deliver(s *seedCrawl, res Result) {
+	select {
+	case s.results <- res:
+		cr.stats.results.Add(1)
+	case <-s.ctx.Done():
+	}
+}
+
+// statusKeyRules canonicalize URLs for the status cache, without the rules
+// that can change the response.
+var statusKeyRules = CanonicalRules{SortParams: true, Fragment: true, DefaultPort: true}
+
+// urlStatusEntry is a status cache entry. The status is set once, by the
+// collector's response or by a probe, and concurrent lookups of the same
+// URL wait for a single probe.
+type urlStatusEntry struct {
+	once   sync.Once
+	status int
+}
+
+// statusEntry returns the status cache entry of u.
+func (s *seedCrawl) statusEntry(u string) *urlStatusEntry {
+	entry, _ := s.statuses.LoadOrStore(statusKeyRules.Canonical(u), &urlStatusEntry{})
+	return entry.(*urlStatusEntry)
+}
+
+// registerStatusRecorder records the status of every page the collector
+// fetches for Config.FetchStatus, so those URLs aren't probed again.
+func (cr *Crawler) registerStatusRecorder(c *colly.Collector, s *seedCrawl) {
+	s.probes = make(chan struct{}, cr.cfg.Threads)
+	s.client = &http.Client{
+		Transport: &contextTransport{ctx: s.ctx, base: cr.transport},
+		Jar:       cr.cfg.Jar,
+		// Probes follow redirects like the collector does
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if cr.cfg.DisableRedirects || len(via) >= 10 || !cr.inScope(s, req.URL) {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+	if cr.cfg.Jar == nil {
+		s.client.Jar = collectorJar{c}
+	}
+	// ids maps colly's request IDs to the URL requested, before any redirect
+	var ids sync.Map
+	c.OnRequest(func(r *colly.Request) {
+		// Aborted requests never get a response to remove them
+		if s.ctx.Err() != nil || !cr.inScope(s, r.URL) {
+			return
+		}
+		ids.Store(r.ID, r.URL.String())
+	})
+	record := func(r *colly.Response) {
+		if u, ok := ids.LoadAndDelete(r.Request.ID); ok && r.StatusCode != 0 {
+			entry := s.statusEntry(u.(string))
+			entry.once.Do(func() { entry.status = r.StatusCode })
+		}
+	}
+	c.OnResponse(record)
+	c.OnError(func(r *colly.Response, _ error) {
+		if r != nil && r.Request != nil {
+			record(r)
+		}
+	})
+}
+
+// urlStatus returns the final HTTP status of u after redirects, caching
+// lookups for the lifetime of the seed. Unless the collector fetched u
+// already, a HEAD request is sent with the crawl's headers and cookies. It
+// returns 0 if u can't be fetched.
+func (cr *Crawler) urlStatus(s *seedCrawl, u string) int {
+	entry := s.statusEntry(u)
+	entry.once.Do(func() {
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodHead, u, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("User-Agent", cr.cfg.UserAgent)
+		for header, value := range cr.headersFor(req.URL.Hostname()) {
+			req.Header.Set(header, value)
+		}
+		if resp, err := s.client.Do(req); err == nil {
+			resp.Body.Close()
+			entry.status = resp.StatusCode
+		}
+	})
+	return entry.status
+}
+
+// hostOf returns the hostname of u, or "" if it can't be parsed.
+func hostOf(u string) string {
+	host, _ := extractHostname(u)
+	return host
+}
+
+// extractHostname() extracts the hostname from a URL and returns it
+func extractHostname(urlString string) (string, error) {
+	u, err := url.Parse(urlString)
+	if err != nil || !u.IsAbs() {
+		return "", errors.New("Input must be a valid absolute URL")
+	}
+
+	return u.Hostname(), nil
+}
+
+// errStatus is returned for unexpected HTTP status codes.
+type errStatus int
+
+func (e errStatus) Error() string {
+	return fmt.Sprintf("unexpected status %d", int(e))
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig returns the CLI defaults for crawling local test servers.
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Threads = 2
+	cfg.IPPolicy = nil
+	return cfg
+}
+
+// crawl runs a crawler with cfg over seeds and returns every result.
+func crawl(t *testing.T, cfg Config, seeds ...string) []Result {
+	t.Helper()
+	cr, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run(t, cr, seeds...)
+}
+
+// run runs cr over seeds and returns every result.
+func run(t *testing.T, cr *Crawler, seeds ...string) []Result {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ch := make(chan string, len(seeds))
+	for _, seed := range seeds {
+		ch <- seed
+	}
+	close(ch)
+	var results []Result
+	for res := range cr.Run(ctx, ch) {
+		results = append(results, res)
+	}
+	return results
+}
+
+// findResult returns the first result with the given source and URL.
+func findResult(results []Result, source, u string) (Result, bool) {
+	for _, res := range results {
+		if res.Source == source && res.URL == u {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// scopeHost returns the host:port scope rule of a test server.
+func scopeHost(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+func TestFetchStatusProbeRedirects(t *testing.T) {
+	var outside atomic.Int64
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outside.Add(1)
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/away">away</a>`)
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := testConfig()
+	// Links are reported but not crawled, so their status comes from a probe
+	cfg.Depth = 1
+	cfg.FetchStatus = true
+	var err error
+	if cfg.Scope, err = NewScope(scopeHost(t, server)); err != nil {
+		t.Fatal(err)
+	}
+	results := crawl(t, cfg, server.URL+"/")
+
+	res, ok := findResult(results, "href", server.URL+"/away")
+	if !ok {
+		t.Fatalf("link not reported in %+v", results)
+	}
+	if res.URLStatus != http.StatusFound {
+		t.Errorf("URLStatus = %d, want %d", res.URLStatus, http.StatusFound)
+	}
+	if n := outside.Load(); n != 0 {
+		t.Errorf("out of scope server was requested %d times", n)
+	}
+}
@@ -0,0 +1,198 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// cssRef is a URL referenced from CSS.
+type cssRef struct {
+	URL string
+	// Import is set for @import rules, whose targets are stylesheets.
+	Import bool
+}
+
+// extractCSSURLs returns the url(), @import and image-set() references of
+// a stylesheet, a <style> block or a style attribute, in source order and
+// without duplicates. data: URIs and fragment-only references are skipped.
+func extractCSSURLs(css string) []cssRef {
+	var refs []cssRef
+	seen := make(map[string]bool)
+	add := func(u string, isImport bool) {
+		u = strings.TrimSpace(u)
+		if u == "" || strings.HasPrefix(u, "#") || strings.HasPrefix(strings.ToLower(u), "data:") || seen[u] {
+			return
+		}
+		seen[u] = true
+		refs = append(refs, cssRef{URL: u, Import: isImport})
+	}
+
+	// imageSetDepth is the paren depth at which the innermost image-set()
+	// was opened, or -1 outside image-set().
+	depth, imageSetDepth := 0, -1
+	for i := 0; i < len(css); {
+		switch {
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return refs
+			}
+			i += end + 4
+		case css[i] == '"' || css[i] == '\'':
+			s, n := cssString(css[i:])
+			if imageSetDepth >= 0 {
+				add(s, false)
+			}
+			i += n
+		case hasPrefixFold(css[i:], "@import"):
+			i += len("@import")
+			i += skipCSSSpace(css[i:])
+			if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+				s, n := cssString(css[i:])
+				add(s, true)
+				i += n
+			} else if hasPrefixFold(css[i:], "url(") && cssIdentBoundary(css, i) {
+				s, n := cssURLArg(css[i+4:])
+				add(s, true)
+				i += 4 + n
+			}
+		case hasPrefixFold(css[i:], "url(") && cssIdentBoundary(css, i):
+			s, n := cssURLArg(css[i+4:])
+			add(s, false)
+			i += 4 + n
+		case hasPrefixFold(css[i:], "image-set(") && (cssIdentBoundary(css, i) || strings.HasSuffix(strings.ToLower(css[:i]), "-webkit-")):
+			if imageSetDepth < 0 {
+				imageSetDepth = depth
+			}
+			depth++
+			i += len("image-set(")
+		case css[i] == '(':
+			depth++
+			i++
+		case css[i] == ')':
+			depth--
+			if depth <= imageSetDepth {
+				imageSetDepth = -1
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return refs
+}
+
+// cssString reads the quoted string at the start of s, returning its
+// unescaped value and the number of bytes consumed.
+func cssString(s string) (string, int) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] != '\n' {
+					b.WriteByte(s[i])
+				}
+			}
+		case quote:
+			return b.String(), i + 1
+		case '\n':
+			// unterminated string
+			return b.String(), i
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), len(s)
+}
+
+// cssURLArg reads the argument of url( up to the closing paren, returning
+// it and the number of bytes consumed including the paren.
+func cssURLArg(s string) (string, int) {
+	i := skipCSSSpace(s)
+	var value string
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		v, n := cssString(s[i:])
+		value = v
+		i += n
+		end := strings.IndexByte(s[i:], ')')
+		if end < 0 {
+			return value, len(s)
+		}
+		return value, i + end + 1
+	}
+	end := strings.IndexByte(s[i:], ')')
+	if end < 0 {
+		return strings.TrimSpace(s[i:]), len(s)
+	}
+	return strings.TrimSpace(s[i : i+end]), i + end + 1
+}
+
+func skipCSSSpace(s string) int {
+	i := 0
+	for i < len(s) && strings.IndexByte(" \t\r\n\f", s[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// cssIdentBoundary reports whether position i of s starts a new
+// identifier, so that e.g. "myurl(" is not taken for "url(".
+func cssIdentBoundary(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	c := s[i-1]
+	return !(c == '-' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// isCSSResponse reports whether r is a stylesheet.
+func isCSSResponse(r *colly.Response) bool {
+	if strings.Contains(r.Headers.Get("Content-Type"), "text/css") {
+		return true
+	}
+	return strings.HasSuffix(strings.ToLower(r.Request.URL.Path), ".css")
+}
+
+// registerCSSExtractors reports the URLs referenced by stylesheets, inline
+// <style> blocks and style attributes. Stylesheets pulled in with @import
+// are crawled as well.
+func (cr *Crawler) registerCSSExtractors(c *colly.Collector, s *seedCrawl) {
+	c.OnResponse(func(r *colly.Response) {
+		if !isCSSResponse(r) {
+			return
+		}
+		for _, ref := range extractCSSURLs(string(r.Body)) {
+			if ref.Import {
+				cr.emitFrom(s, r.Request, r, ref.URL, Result{Source: "css-import"})
+				r.Request.Visit(ref.URL)
+			} else {
+				cr.emitFrom(s, r.Request, r, ref.URL, Result{Source: "css-url"})
+			}
+		}
+	})
+
+	c.OnHTML("style", func(e *colly.HTMLElement) {
+		for _, ref := range extractCSSURLs(e.Text) {
+			if ref.Import {
+				cr.emit(s, e, ref.URL, "", "css-import")
+				e.Request.Visit(ref.URL)
+			} else {
+				cr.emit(s, e, ref.URL, "", "css-url")
+			}
+		}
+	})
+
+	c.OnHTML("[style]", func(e *colly.HTMLElement) {
+		for _, ref := range extractCSSURLs(e.Attr("style")) {
+			cr.emit(s, e, ref.URL, "style", "css-url")
+		}
+	})
+}
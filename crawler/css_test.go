@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractCSSURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want []cssRef
+	}{
+		{"unquoted url", `a { background: url(/img/bg.png) }`, []cssRef{{URL: "/img/bg.png"}}},
+		{"double quoted url", `a { background: url("/img/bg.png") }`, []cssRef{{URL: "/img/bg.png"}}},
+		{"single quoted url", `a { background: url('/img/bg.png') }`, []cssRef{{URL: "/img/bg.png"}}},
+		{"spaces in url", `a { background: url(  "/img/bg.png"  ) }`, []cssRef{{URL: "/img/bg.png"}}},
+		{"escaped quote", `a { background: url("/img/a\"b.png") }`, []cssRef{{URL: `/img/a"b.png`}}},
+		{"upper case", `a { background: URL(/img/bg.png) }`, []cssRef{{URL: "/img/bg.png"}}},
+		{"import string", `@import "/css/base.css";`, []cssRef{{URL: "/css/base.css", Import: true}}},
+		{"import url", `@import url(/css/base.css) screen;`, []cssRef{{URL: "/css/base.css", Import: true}}},
+		{"import quoted url", `@IMPORT url('/css/base.css');`, []cssRef{{URL: "/css/base.css", Import: true}}},
+		{"data uri", `a { background: url(data:image/png;base64,iVBORw0KGgo=) }`, nil},
+		{"quoted data uri", `a { background: url("DATA:image/svg+xml,<svg></svg>") }`, nil},
+		{"fragment", `a { filter: url(#blur) }`, nil},
+		{"empty", `a { background: url() }`, nil},
+		{"not a url function", `a { background: myurl(/x.png) }`, nil},
+		{"comment", `/* url(/old.png) */ a { background: url(/new.png) }`, []cssRef{{URL: "/new.png"}}},
+		{"unterminated comment", `a { background: url(/a.png) } /* url(/b.png)`, []cssRef{{URL: "/a.png"}}},
+		{"duplicates", `a { background: url(/a.png) } b { background: url("/a.png") }`, []cssRef{{URL: "/a.png"}}},
+		{"image-set", `a { background: image-set("/a.png" 1x, url(/b.png) 2x) }`, []cssRef{{URL: "/a.png"}, {URL: "/b.png"}}},
+		{"webkit image-set", `a { background: -webkit-image-set("/a.png" 1x) }`, []cssRef{{URL: "/a.png"}}},
+		{"strings outside image-set", `a { content: "/not-a-url"; font-family: "Open Sans" }`, nil},
+		{"source order", `@import "/a.css"; a { background: url(/b.png) }`, []cssRef{{URL: "/a.css", Import: true}, {URL: "/b.png"}}},
+		{"unterminated url", `a { background: url(/a.png`, []cssRef{{URL: "/a.png"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractCSSURLs(tt.css); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractCSSURLs(%q) = %+v, want %+v", tt.css, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSSExtractors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head>
+			<link rel="stylesheet" href="/css/site.css">
+			<style>@import "/css/inline.css"; h1 { background: url(/img/head.png) }</style>
+		</head><body>
+			<div style="background-image: url('/img/div.png')"></div>
+			<span style="background: url(data:image/gif;base64,R0lGOD==)"></span>
+		</body></html>`)
+	})
+	mux.HandleFunc("/css/site.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		fmt.Fprint(w, `@import url(theme.css); body { background: url("../img/body.png") }`)
+	})
+	mux.HandleFunc("/css/theme.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		fmt.Fprint(w, `p { background: url(/img/theme.png) }`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Depth = 3
+	results := crawl(t, cfg, server.URL+"/")
+
+	tests := []struct {
+		source, path, where, attr string
+	}{
+		{"css-import", "/css/inline.css", "/", ""},
+		{"css-url", "/img/head.png", "/", ""},
+		{"css-url", "/img/div.png", "/", "style"},
+		{"css-import", "/css/theme.css", "/css/site.css", ""},
+		{"css-url", "/img/body.png", "/css/site.css", ""},
+		{"css-url", "/img/theme.png", "/css/theme.css", ""},
+	}
+	for _, tt := range tests {
+		res, ok := findResult(results, tt.source, server.URL+tt.path)
+		if !ok {
+			t.Errorf("%s %s not reported", tt.source, tt.path)
+			continue
+		}
+		if res.Where != server.URL+tt.where {
+			t.Errorf("%s %s found in %s, want %s", tt.source, tt.path, res.Where, tt.where)
+		}
+		if res.Attribute != tt.attr {
+			t.Errorf("%s %s attribute = %q, want %q", tt.source, tt.path, res.Attribute, tt.attr)
+		}
+	}
+	for _, res := range results {
+		if res.Source == "css-url" && res.Tag == "span" {
+			t.Errorf("data: URI reported: %s", res.URL)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	// maxSitemaps bounds the number of sitemap files fetched per seed.
+	maxSitemaps = 100
+	// maxSitemapDepth bounds sitemap index nesting.
+	maxSitemapDepth = 5
+	// maxDiscoverBody is the largest robots.txt or sitemap read, after
+	// decompression, when Config.MaxSize is not set.
+	maxDiscoverBody = 50 * 1024 * 1024
+)
+
+// sitemap is either a <urlset> or a <sitemapindex> document.
+type sitemap struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// discovery is the state of one seed's robots.txt and sitemap walk.
+type discovery struct {
+	s       *seedCrawl
+	c       *colly.Collector
+	client  *http.Client
+	fetched map[string]bool
+}
+
+// discover reports the robots.txt rules and sitemap entries of the seed
+// host, and queues them on c when Config.DiscoverCrawl is set.
+func (cr *Crawler) discover(s *seedCrawl, c *colly.Collector) {
+	seedURL, err := url.Parse(s.seed)
+	if err != nil {
+		return
+	}
+	root := &url.URL{Scheme: seedURL.Scheme, Host: seedURL.Host, Path: "/"}
+	d := &discovery{
+		s: s,
+		c: c,
+		client: &http.Client{
+			Transport: &contextTransport{ctx: s.ctx, base: cr.transport},
+			Jar:       cr.cfg.Jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 || !cr.inScope(s, req.URL) {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+		fetched: make(map[string]bool),
+	}
+
+	sitemaps := cr.discoverRobots(d, root)
+	sitemaps = append(sitemaps, root.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String())
+	for _, sm := range sitemaps {
+		cr.discoverSitemap(d, sm, 0)
+	}
+}
+
+// discoverRobots reports every Allow, Disallow and Sitemap entry of the
+// host's robots.txt and returns the sitemap URLs.
+func (cr *Crawler) discoverRobots(d *discovery, root *url.URL) []string {
+	robotsURL := root.ResolveReference(&url.URL{Path: "/robots.txt"})
+	resp, body, err := cr.discoverFetch(d, robotsURL.String())
+	if err != nil {
+		return nil
+	}
+
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		directive := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		if value == "" {
+			continue
+		}
+
+		switch directive {
+		case "allow", "disallow":
+			// Cut wildcard patterns down to their literal prefix
+			if i := strings.Index(value, "*"); i >= 0 {
+				value = value[:i]
+			}
+			value = strings.TrimSuffix(value, "$")
+			if value == "" || value == "/" {
+				continue
+			}
+		case "sitemap":
+		default:
+			continue
+		}
+
+		ref, err := robotsURL.Parse(value)
+		if err != nil {
+			continue
+		}
+		if directive == "sitemap" {
+			sitemaps = append(sitemaps, ref.String())
+		}
+		cr.discovered(d, resp, ref.String(), "robots", directive)
+	}
+	return sitemaps
+}
+
+// discoverSitemap reports the entries of a sitemap, recursing into
+// sitemap indexes. Gzip compressed sitemaps are supported.
+func (cr *Crawler) discoverSitemap(d *discovery, sitemapURL string, depth int) {
+	if depth > maxSitemapDepth || len(d.fetched) >= maxSitemaps || d.fetched[sitemapURL] {
+		return
+	}
+	d.fetched[sitemapURL] = true
+
+	resp, body, err := cr.discoverFetch(d, sitemapURL)
+	if err != nil {
+		return
+	}
+	var sm sitemap
+	if err := xml.Unmarshal(body, &sm); err != nil {
+		log.Printf("[SITEMAP ERROR]: %s: %v\n", sitemapURL, err)
+		return
+	}
+
+	for _, u := range sm.URLs {
+		if loc := sitemapLoc(resp, u.Loc); loc != "" {
+			cr.discovered(d, resp, loc, "sitemap", "loc")
+		}
+	}
+	for _, child := range sm.Sitemaps {
+		loc := sitemapLoc(resp, child.Loc)
+		if loc == "" {
+			continue
+		}
+		cr.discovered(d, resp, loc, "sitemap", "sitemap")
+		cr.discoverSitemap(d, loc, depth+1)
+	}
+}
+
+// sitemapLoc resolves a <loc> of the sitemap served by resp. Locs should
+// be absolute, but relative ones are seen in the wild.
+func sitemapLoc(resp *http.Response, loc string) string {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		return ""
+	}
+	u, err := resp.Request.URL.Parse(loc)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// discoverFetch GETs u with the configured headers and returns its body,
+// transparently gunzipping .xml.gz sitemaps.
+func (cr *Crawler) discoverFetch(d *discovery, u string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(d.s.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cr.inScope(d.s, req.URL) {
+		return nil, nil, errors.New("out of scope")
+	}
+	req.Header.Set("User-Agent", cr.cfg.UserAgent)
+	for header, value := range cr.headersFor(req.URL.Hostname()) {
+		req.Header.Set(header, value)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errStatus(resp.StatusCode)
+	}
+
+	limit := int64(maxDiscoverBody)
+	if cr.cfg.MaxSize > 0 {
+		limit = int64(cr.cfg.MaxSize) * 1024
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer zr.Close()
+		if body, err = io.ReadAll(io.LimitReader(zr, limit)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resp, body, nil
+}
+
+// discovered sends a robots or sitemap result and, with
+// Config.DiscoverCrawl, queues it for crawling.
+func (cr *Crawler) discovered(d *discovery, resp *http.Response, link string, source string, attr string) {
+	if len(cr.cfg.Keywords) != 0 && !containsKeyword(link, cr.cfg.Keywords) {
+		return
+	}
+	cr.send(d.s, Result{
+		Source:      source,
+		URL:         link,
+		Where:       resp.Request.URL.String(),
+		Seed:        d.s.seed,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Attribute:   attr,
+	})
+	if cr.cfg.DiscoverCrawl && attr != "sitemap" {
+		d.c.Visit(link)
+	}
+}
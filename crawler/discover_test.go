@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	fmt.Fprint(zw, `<urlset><url><loc>/from-gzip</loc></url></urlset>`)
+	zw.Close()
+
+	var mu sync.Mutex
+	fetched := make(map[string]bool)
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path] = true
+		mu.Unlock()
+	})
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\n"+
+			"Disallow: /private/*.json$ # secrets\n"+
+			"Disallow: /admin\n"+
+			"Allow: /hidden/\n"+
+			"Disallow:\n"+
+			"Sitemap: /index.xml\n"+
+			"\nUser-agent: otherbot\n"+
+			"Disallow: /\n")
+	})
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/pages.xml.gz</loc></sitemap></sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/pages.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gz.Bytes())
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<urlset><url><loc> %s/news </loc></url></urlset>`, server.URL)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Depth = 1
+	cfg.Discover = true
+	cfg.DiscoverCrawl = true
+	cfg.RespectRobots = true
+	results := crawl(t, cfg, server.URL+"/")
+
+	tests := []struct {
+		source, url, attr, where string
+	}{
+		{"robots", server.URL + "/private/", "disallow", "/robots.txt"},
+		{"robots", server.URL + "/admin", "disallow", "/robots.txt"},
+		{"robots", server.URL + "/hidden/", "allow", "/robots.txt"},
+		{"robots", server.URL + "/index.xml", "sitemap", "/robots.txt"},
+		{"sitemap", server.URL + "/pages.xml.gz", "sitemap", "/index.xml"},
+		{"sitemap", server.URL + "/from-gzip", "loc", "/pages.xml.gz"},
+		{"sitemap", server.URL + "/news", "loc", "/sitemap.xml"},
+	}
+	for _, tt := range tests {
+		res, ok := findResult(results, tt.source, tt.url)
+		if !ok {
+			t.Errorf("%s %s not reported", tt.source, tt.url)
+			continue
+		}
+		if res.Attribute != tt.attr || res.Where != server.URL+tt.where {
+			t.Errorf("%s %s reported as %s in %s, want %s in %s", tt.source, tt.url, res.Attribute, res.Where, tt.attr, tt.where)
+		}
+	}
+	if _, ok := findResult(results, "robots", server.URL+"/"); ok {
+		t.Errorf("Disallow: / reported")
+	}
+
+	// Discovered URLs are crawled, except where robots.txt disallows it
+	mu.Lock()
+	defer mu.Unlock()
+	for path, want := range map[string]bool{"/hidden/": true, "/news": true, "/from-gzip": true, "/private/": true, "/admin": false} {
+		if fetched[path] != want {
+			t.Errorf("%s fetched = %v, want %v", path, fetched[path], want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+)
+
+// urlRegex matches absolute URLs in page text and JavaScript literals.
+var urlRegex = regexp.MustCompile(`(?i)(?:(?:https?|ftp|smtp|unknown|sftp|file|data|telnet|ssh|ws|wss|git|svn|gopher):\/\/)(?:(?:[^\s:@'"]+(?::[^\s:@'"]*)?@)?(?:[_A-Z0-9.-]+|\[[_A-F0-9]*:[_A-F0-9:]+\])(?::\d{1,5})?)(?:\/[^\s'"]*)?(?:\?[^\s'"]*)?(?:#[^\s'"]*)?`)
+
+// extractURLsFromJS returns the absolute URLs found in the string and
+// template literals of jsCode, without duplicates.
+func extractURLsFromJS(jsCode string) []string {
+	return jsLiteralURLs(jsLiterals(jsCode))
+}
+
+// jsLiteralURLs returns the absolute URLs found in lits, in source order
+// and without duplicates.
+func jsLiteralURLs(lits []jsLiteral) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, lit := range lits {
+		if !strings.Contains(lit.Value, "://") {
+			continue
+		}
+		for _, match := range urlRegex.FindAllString(lit.Value, -1) {
+			if !seen[match] {
+				seen[match] = true
+				urls = append(urls, match)
+			}
+		}
+	}
+	return urls
+}
+
+// extractURLsFromText returns the absolute URLs found in body, without
+// duplicates.
+func extractURLsFromText(body string) []string {
+	matches := urlRegex.FindAllString(body, -1)
+
+	// Deduplicate the matches (if needed)
+	uniqueURLs := make(map[string]bool)
+	for _, match := range matches {
+		uniqueURLs[match] = true
+	}
+
+	// Convert unique URLs to a slice
+	var urls []string
+	for url := range uniqueURLs {
+		urls = append(urls, url)
+	}
+
+	return urls
+}
+
+// Function to check if any keyword is present in the URL
+func containsKeyword(url string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(url, keyword) {
+			return true
+		}
+	}
+	return false
+}
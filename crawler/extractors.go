@@ -0,0 +1,241 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// registerExtractors registers the HTML callbacks that pull URLs out of
+// every page crawled for s.
+func (cr *Crawler) registerExtractors(c *colly.Collector, s *seedCrawl) {
+	c.OnHTML("video[src], audio[src], embed[src], track[src], area[href], applet[archive], base[href], bgsound[src], body[background], link[type='application/rss+xml'], link[type='application/atom+xml'], link[type='application/xml'], img[src*='.webp'], link[rel='manifest'], meta[property^='og:'], meta[name^='twitter:'], a[href$='.xml'], *[src^='data:'], script[src^='ws://'], script[src^='wss://'], frame[src], frameset[frameborder='1'], a[href], script[src], form[action], script, link[rel=stylesheet], [src], iframe, img, button[href], a[href], form[action], select", func(e *colly.HTMLElement) {
+		src := e.Attr("src")
+		href := e.Attr("href")
+		archive := e.Attr("archive")
+		background := e.Attr("background")
+		feedURL := e.Attr("href")
+		webpURL := e.Attr("src")
+		manifestURL := e.Attr("href")
+		property := e.Attr("property")
+		name := e.Attr("name")
+		content := e.Attr("content")
+		sitemapURL := e.Attr("href")
+		dataURI := e.Attr("src")
+		websocketURL := e.Attr("src")
+		frameURL := e.Attr("src")
+		link := e.Attr("href")
+		// Only script text is JavaScript; URLs in other text are found by
+		// the custom pattern below
+		var urls []string
+		if e.Name == "script" {
+			urls = extractURLsFromJS(e.Text)
+		}
+		cssURL := e.Attr("href")
+		srcURL := e.Attr("src")
+		link2 := e.Attr("href")
+
+		if src != "" {
+			cr.emit(s, e, src, "src", "video")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		if src != "" {
+			cr.emit(s, e, src, "src", "audio")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		if src != "" {
+			cr.emit(s, e, src, "src", "embed")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		if src != "" {
+			cr.emit(s, e, src, "src", "track")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		if href != "" {
+			cr.emit(s, e, href, "href", "area")
+			e.Request.Visit(e.Request.AbsoluteURL(href))
+		}
+
+		if archive != "" {
+			cr.emit(s, e, archive, "archive", "applet")
+			e.Request.Visit(e.Request.AbsoluteURL(archive))
+		}
+
+		if href != "" {
+			cr.emit(s, e, href, "href", "base")
+			e.Request.Visit(e.Request.AbsoluteURL(href))
+		}
+
+		if src != "" {
+			cr.emit(s, e, src, "src", "bgsound")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		if background != "" {
+			cr.emit(s, e, background, "background", "body-background")
+			e.Request.Visit(e.Request.AbsoluteURL(background))
+		}
+
+		if feedURL != "" {
+			cr.emit(s, e, feedURL, "href", "feed")
+			e.Request.Visit(e.Request.AbsoluteURL(feedURL))
+		}
+
+		if webpURL != "" {
+			cr.emit(s, e, webpURL, "src", "webp-image")
+			e.Request.Visit(e.Request.AbsoluteURL(webpURL))
+		}
+
+		if manifestURL != "" {
+			cr.emit(s, e, manifestURL, "href", "manifest")
+			e.Request.Visit(e.Request.AbsoluteURL(manifestURL))
+		}
+
+		if property != "" && content != "" {
+			cr.emit(s, e, content, "content", "social-media-"+property)
+			e.Request.Visit(e.Request.AbsoluteURL(content))
+		} else if name != "" && content != "" {
+			cr.emit(s, e, content, "content", "social-media-"+name)
+			e.Request.Visit(e.Request.AbsoluteURL(content))
+		}
+
+		if sitemapURL != "" {
+			cr.emit(s, e, sitemapURL, "href", "sitemap")
+			e.Request.Visit(e.Request.AbsoluteURL(sitemapURL))
+		}
+
+		if dataURI != "" {
+			cr.emit(s, e, dataURI, "src", "data-uri")
+			e.Request.Visit(e.Request.AbsoluteURL(dataURI))
+		}
+
+		if websocketURL != "" {
+			cr.emit(s, e, websocketURL, "src", "websocket")
+			e.Request.Visit(e.Request.AbsoluteURL(websocketURL))
+		}
+
+		if frameURL != "" {
+			cr.emit(s, e, frameURL, "src", "frame")
+			e.Request.Visit(e.Request.AbsoluteURL(frameURL))
+		}
+
+		cr.emit(s, e, link, "href", "href")
+		e.Request.Visit(e.Request.AbsoluteURL(link))
+
+		cr.emit(s, e, e.Attr("src"), "src", "script")
+
+		for _, url := range urls {
+			cr.emit(s, e, url, "", "jscode")
+			e.Request.Visit(e.Request.AbsoluteURL(url))
+		}
+
+		cr.emit(s, e, cssURL, "href", "css")
+
+		if srcURL != "" {
+			cr.emit(s, e, srcURL, "src", "embedded")
+			e.Request.Visit(e.Request.AbsoluteURL(srcURL))
+		}
+
+		if link2 != "" && (strings.HasPrefix(link2, "http://") || strings.HasPrefix(link2, "https://")) {
+			cr.emit(s, e, link2, "href", "interactive")
+			e.Request.Visit(e.Request.AbsoluteURL(link2))
+		}
+	})
+
+	// Extract URLs from all HTML elements and attributes
+	c.OnHTML("*", func(e *colly.HTMLElement) {
+		// Every element is visited, so each only matches its own text
+		body := ownText(e)
+
+		// Extract URLs using the default URL pattern
+		urls := extractURLsFromText(body)
+		for _, url := range urls {
+			cr.emit(s, e, url, "", "custom_REGEX")
+			e.Request.Visit(e.Request.AbsoluteURL(url))
+		}
+
+		// Matches of user patterns are reported, not visited
+		for _, p := range cr.cfg.Patterns {
+			for _, match := range p.match(body) {
+				cr.emit(s, e, match, "", p.Name)
+			}
+		}
+
+		// Check for href attribute
+		href := e.Attr("href")
+		if href != "" {
+			cr.emit(s, e, href, "href", "href")
+			e.Request.Visit(e.Request.AbsoluteURL(href))
+		}
+
+		// Check for src attribute
+		src := e.Attr("src")
+		if src != "" {
+			cr.emit(s, e, src, "src", "src")
+			e.Request.Visit(e.Request.AbsoluteURL(src))
+		}
+
+		//Check for data attributes that may contain URLs
+		e.ForEach("[data-*]", func(_ int, el *colly.HTMLElement) {
+			dataAttr := el.Text
+			if dataAttr != "" {
+				cr.emit(s, e, dataAttr, "", "data")
+				e.Request.Visit(e.Request.AbsoluteURL(dataAttr))
+			}
+		})
+
+		// Check for content attribute in meta tags
+		if e.Name == "meta" {
+			content := e.Attr("content")
+			if content != "" {
+				cr.emit(s, e, content, "content", "meta")
+				e.Request.Visit(e.Request.AbsoluteURL(content))
+			}
+		}
+
+		// Check for URLs in inline JavaScript code
+		if e.Name == "script" {
+			jsCode := e.Text
+			urls := extractURLsFromJS(jsCode)
+			for _, url := range urls {
+				cr.emit(s, e, url, "", "jscode")
+				e.Request.Visit(e.Request.AbsoluteURL(url))
+			}
+		}
+
+		// Check for URLs in CSS files
+		if e.Name == "link" && e.Attr("rel") == "stylesheet" {
+			cssURL := e.Attr("href")
+			cr.emit(s, e, cssURL, "href", "css")
+			e.Request.Visit(e.Request.AbsoluteURL(cssURL))
+		}
+
+		//Check for custom data attributes that may contain URLs
+		e.ForEach("[data-custom-*]", func(_ int, el *colly.HTMLElement) {
+			customDataAttr := el.Text
+			if customDataAttr != "" {
+				cr.emit(s, e, customDataAttr, "", "custom-data")
+				e.Request.Visit(e.Request.AbsoluteURL(customDataAttr))
+			}
+		})
+
+	})
+}
+
+// ownText returns the text nodes directly inside e, one per line, leaving
+// out the text of its child elements.
+func ownText(e *colly.HTMLElement) string {
+	var b strings.Builder
+	e.DOM.Contents().Each(func(_ int, n *goquery.Selection) {
+		if goquery.NodeName(n) == "#text" {
+			b.WriteString(n.Text())
+			b.WriteByte('\n')
+		}
+	})
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestPatternMatchesReportedOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div><section><article><p>
+			key tok_ABC123 and <b>https://nested.example.com/page</b>
+		</p></article></section></div></body></html>`)
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Depth = 1
+	cfg.Patterns = []Pattern{{Name: "token", Regex: regexp.MustCompile(`tok_[A-Z0-9]+`)}}
+	results := crawl(t, cfg, server.URL+"/")
+
+	counts := make(map[string]int)
+	for _, res := range results {
+		counts[res.Source+" "+res.URL]++
+	}
+	for _, key := range []string{
+		"token " + server.URL + "/tok_ABC123",
+		"custom_REGEX https://nested.example.com/page",
+	} {
+		if counts[key] != 1 {
+			t.Errorf("%s reported %d times, want once", key, counts[key])
+		}
+	}
+	if res, _ := findResult(results, "token", server.URL+"/tok_ABC123"); res.Tag != "p" {
+		t.Errorf("match reported for <%s>, want the <p> holding it", res.Tag)
+	}
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// csrfNameRegex matches the names of hidden inputs that look like anti-CSRF
+// tokens.
+var csrfNameRegex = regexp.MustCompile(`(?i)csrf|xsrf|token|nonce|authenticity|requestverification`)
+
+// Form is an HTML form, reported with source "form".
+type Form struct {
+	// Action is the resolved URL the form submits to.
+	Action string `json:"action"`
+	// Method is the upper case method, GET by default.
+	Method string `json:"method"`
+	// Enctype defaults to application/x-www-form-urlencoded.
+	Enctype string      `json:"enctype"`
+	Fields  []FormField `json:"fields,omitempty"`
+	// FileUpload is set if the form has a file input.
+	FileUpload bool `json:"file_upload,omitempty"`
+}
+
+// FormField is a named input, select or textarea of a form.
+type FormField struct {
+	Name string `json:"name"`
+	// Type is the input type, or "select" or "textarea".
+	Type string `json:"type"`
+	// Value is the default value; for a select, the selected option.
+	Value string `json:"value,omitempty"`
+	// Options are the option values of a select.
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	// CSRF is set for hidden inputs that look like anti-CSRF tokens.
+	CSRF bool `json:"csrf,omitempty"`
+}
+
+// parseForm builds the Form record of a form element.
+func parseForm(e *colly.HTMLElement) *Form {
+	f := &Form{
+		Action:  e.Request.AbsoluteURL(e.Attr("action")),
+		Method:  strings.ToUpper(strings.TrimSpace(e.Attr("method"))),
+		Enctype: strings.ToLower(strings.TrimSpace(e.Attr("enctype"))),
+	}
+	if f.Action == "" {
+		f.Action = e.Request.URL.String()
+	}
+	if f.Method == "" {
+		f.Method = "GET"
+	}
+	if f.Enctype == "" {
+		f.Enctype = "application/x-www-form-urlencoded"
+	}
+	e.ForEach("input[name], select[name], textarea[name]", func(_ int, el *colly.HTMLElement) {
+		field := FormField{
+			Name:     el.Attr("name"),
+			Type:     el.Name,
+			Value:    el.Attr("value"),
+			Required: el.DOM.Is("[required]"),
+		}
+		switch el.Name {
+		case "input":
+			field.Type = strings.ToLower(el.Attr("type"))
+			if field.Type == "" {
+				field.Type = "text"
+			}
+			field.CSRF = field.Type == "hidden" && csrfNameRegex.MatchString(field.Name)
+			if field.Type == "file" {
+				f.FileUpload = true
+			}
+		case "select":
+			field.Value = ""
+			el.ForEach("option", func(i int, opt *colly.HTMLElement) {
+				value := opt.Attr("value")
+				if _, ok := opt.DOM.Attr("value"); !ok {
+					value = strings.TrimSpace(opt.Text)
+				}
+				field.Options = append(field.Options, value)
+				if i == 0 || opt.DOM.Is("[selected]") {
+					field.Value = value
+				}
+			})
+		case "textarea":
+			field.Value = el.Text
+		}
+		f.Fields = append(f.Fields, field)
+	})
+	return f
+}
+
+// registerFormExtractor reports every form with its Form record, feeds the
+// inputs to Crawler.Endpoints when Config.Params is set and submits the
+// form when Config.SubmitForms is set.
+func (cr *Crawler) registerFormExtractor(c *colly.Collector, s *seedCrawl) {
+	c.OnHTML("form", func(e *colly.HTMLElement) {
+		f := parseForm(e)
+		if cr.cfg.Params {
+			cr.params.addForm(f)
+		}
+		cr.emitFrom(s, e.Request, e.Response, f.Action, Result{Source: "form", Tag: e.Name, Attribute: "action", Form: f})
+		if cr.cfg.SubmitForms {
+			cr.submitForm(s, e, f)
+		}
+	})
+}
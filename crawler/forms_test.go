@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFormExtraction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>
+			<form action="/search">
+				<input name="q" required>
+				<input type="submit" name="go" value="Search">
+				<button>unnamed</button>
+			</form>
+			<form action="/upload" method="post" enctype="Multipart/Form-Data">
+				<input type="hidden" name="csrf_token" value="t0k3n">
+				<input type="hidden" name="folder" value="docs">
+				<input type="file" name="doc">
+				<select name="kind">
+					<option value="a">A</option>
+					<option selected>B</option>
+				</select>
+				<textarea name="note">hello</textarea>
+			</form>
+			<form method="dialog"><input type="email" name="mail"></form>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Depth = 1
+	results := crawl(t, cfg, server.URL+"/page")
+
+	tests := []Form{
+		{
+			Action:  server.URL + "/search",
+			Method:  "GET",
+			Enctype: "application/x-www-form-urlencoded",
+			Fields: []FormField{
+				{Name: "q", Type: "text", Required: true},
+				{Name: "go", Type: "submit", Value: "Search"},
+			},
+		},
+		{
+			Action:  server.URL + "/upload",
+			Method:  "POST",
+			Enctype: "multipart/form-data",
+			Fields: []FormField{
+				{Name: "csrf_token", Type: "hidden", Value: "t0k3n", CSRF: true},
+				{Name: "folder", Type: "hidden", Value: "docs"},
+				{Name: "doc", Type: "file"},
+				{Name: "kind", Type: "select", Value: "B", Options: []string{"a", "B"}},
+				{Name: "note", Type: "textarea", Value: "hello"},
+			},
+			FileUpload: true,
+		},
+		{
+			Action:  server.URL + "/page",
+			Method:  "DIALOG",
+			Enctype: "application/x-www-form-urlencoded",
+			Fields:  []FormField{{Name: "mail", Type: "email"}},
+		},
+	}
+	for _, want := range tests {
+		res, ok := findResult(results, "form", want.Action)
+		if !ok {
+			t.Errorf("form %s not reported", want.Action)
+			continue
+		}
+		if res.Tag != "form" || res.Attribute != "action" || res.Where != server.URL+"/page" {
+			t.Errorf("form %s reported as <%s %s> on %s", want.Action, res.Tag, res.Attribute, res.Where)
+		}
+		if res.Form == nil || !reflect.DeepEqual(*res.Form, want) {
+			t.Errorf("form %s = %+v, want %+v", want.Action, res.Form, want)
+		}
+	}
+}
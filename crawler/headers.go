@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// ParseHeaders does validation of headers input and returns it as a
+// formatted map. Headers are separated by ";;", e.g. "A: b;;C: d".
+func ParseHeaders(rawHeaders string) (map[string]string, error) {
+	if rawHeaders == "" {
+		return nil, nil
+	}
+	if !strings.Contains(rawHeaders, ":") {
+		return nil, errors.New("headers flag not formatted properly (no colon to separate header and value)")
+	}
+
+	headers := make(map[string]string)
+	for _, header := range strings.Split(rawHeaders, ";;") {
+		var parts []string
+		if strings.Contains(header, ": ") {
+			parts = strings.SplitN(header, ": ", 2)
+		} else if strings.Contains(header, ":") {
+			parts = strings.SplitN(header, ":", 2)
+		} else {
+			continue
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+// HeaderRule sets Headers on every request whose host matches Host. Host is
+// either an exact hostname or a wildcard such as "*.example.com", which
+// matches example.com and all of its subdomains.
+type HeaderRule struct {
+	Host    string
+	Headers map[string]string
+}
+
+// matches reports whether the rule applies to host.
+func (r HeaderRule) matches(host string) bool {
+	host = strings.ToLower(host)
+	pattern := strings.ToLower(r.Host)
+	if strings.HasPrefix(pattern, "*.") {
+		domain := pattern[2:]
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// LoadHeaderFile reads headers from a file. Each line holds one
+// "Name: value" header; blank lines and lines starting with # are ignored.
+// A "[host]" line starts a section whose headers only apply to that host,
+// e.g.
+//
+//	User-Agent: paxkk
+//	[api.target.com]
+//	Authorization: Bearer xyz
+//
+// Headers before the first section apply to every request.
+func LoadHeaderFile(filename string) (map[string]string, []HeaderRule, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	global := make(map[string]string)
+	var rules []HeaderRule
+	current := global
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			host := strings.TrimSpace(line[1 : len(line)-1])
+			if host == "" {
+				return nil, nil, fmt.Errorf("%s:%d: empty host section", filename, lineNo)
+			}
+			rules = append(rules, HeaderRule{Host: host, Headers: make(map[string]string)})
+			current = rules[len(rules)-1].Headers
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, nil, fmt.Errorf("%s:%d: header not formatted properly (no colon to separate header and value)", filename, lineNo)
+		}
+		current[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return global, rules, nil
+}
+
+// headersFor returns the global headers merged with every host rule that
+// matches host, so host specific values win.
+func (cr *Crawler) headersFor(host string) map[string]string {
+	headers := make(map[string]string, len(cr.cfg.Headers))
+	for header, value := range cr.cfg.Headers {
+		headers[header] = value
+	}
+	for _, rule := range cr.cfg.HeaderRules {
+		if rule.matches(host) {
+			for header, value := range rule.Headers {
+				headers[header] = value
+			}
+		}
+	}
+	return headers
+}
+
+// applyHostHeader moves a Host header set by -h or -H-file to req.Host.
+// net/http ignores Header["Host"], so a virtual host override would
+// otherwise be dropped silently.
+func applyHostHeader(req *http.Request) {
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+}
+
+// setHeaders applies the configured headers to a collector request.
+func (cr *Crawler) setHeaders(r *colly.Request) {
+	for header, value := range cr.headersFor(r.URL.Hostname()) {
+		r.Headers.Set(header, value)
+	}
+}
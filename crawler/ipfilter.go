@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// allowSeed resolves the host of seed and checks its addresses against
+// Config.IPPolicy. Seeds that can't be resolved are counted as skipped,
+// denied ones as denied with the reason recorded for Crawler.DeniedHosts.
+// The lookup is abandoned, and the seed counted as cancelled, once ctx is
+// done.
+func (cr *Crawler) allowSeed(ctx context.Context, seed string) bool {
+	host := hostOf(seed)
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if ctx.Err() != nil {
+			cr.stats.cancelled.Add(1)
+			return false
+		}
+		log.Printf("[DNS ERROR]: Unable to resolve host %s: %v\n", host, err)
+		cr.stats.skipped.Add(1)
+		return false
+	}
+
+	if len(addrs) == 0 {
+		log.Printf("[NO IP ADDRESSES]: No IP addresses found for host %s\n", host)
+		cr.stats.skipped.Add(1)
+		return false
+	}
+
+	// If any IP is denied, skip the seed
+	for _, addr := range addrs {
+		if reason := cr.cfg.IPPolicy.check(addr.IP); reason != "" {
+			log.Printf("[SKIPPED URL]: %s, %s\n", seed, reason)
+			cr.stats.denied.Add(1)
+			cr.denied.LoadOrStore(host, reason)
+			return false
+		}
+	}
+	return true
+}
+
+// DeniedHost is a seed host skipped because of Config.IPPolicy.
+type DeniedHost struct {
+	Host string
+	// Reason names the denied address and the range or AS it is in.
+	Reason string
+}
+
+// DeniedHosts returns the seed hosts skipped because of Config.IPPolicy,
+// sorted by host.
+func (cr *Crawler) DeniedHosts() []DeniedHost {
+	var hosts []DeniedHost
+	cr.denied.Range(func(host, reason any) bool {
+		hosts = append(hosts, DeniedHost{Host: host.(string), Reason: reason.(string)})
+		return true
+	})
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// Function to check if a URL is alive by making a HEAD request with the
+// configured headers, cookie jar, proxy and TLS settings. It gives up
+// early, returning false, once ctx is done.
+func (cr *Crawler) isURLAlive(ctx context.Context, url string) bool {
+	maxRetries := 4
+	headers := cr.headersFor(hostOf(url))
+	client := http.Client{Transport: &contextTransport{ctx: ctx, base: cr.transport}, Jar: cr.cfg.Jar}
+	for i := 0; i < maxRetries; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			log.Printf("[INVALID URL]: %s\n", url)
+			return false
+		}
+		for header, value := range headers {
+			req.Header.Set(header, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			log.Printf("[NETWORK ERROR]: %s, Retry #%d\n", url, i+1)
+			if !sleepContext(ctx, 15*time.Second) {
+				return false
+			}
+			continue
+		}
+		resp.Body.Close()
+
+		var backoff time.Duration
+		if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+			return true
+		} else if resp.StatusCode == http.StatusTooManyRequests {
+			log.Printf("[RATE LIMITING]: %s, Status Code: %d, Retry #%d\n", url, resp.StatusCode, i+1)
+			backoff = 20 * time.Second
+		} else if resp.StatusCode >= 500 {
+			log.Printf("[RETRYING]: %s - Status: %d\n", url, resp.StatusCode)
+			backoff = 10 * time.Second
+		} else if resp.StatusCode == 404 || resp.StatusCode == 403 || resp.StatusCode == 401 || resp.StatusCode == 400 {
+			log.Printf("[SKIPPING]: %s - Status: %d\n", url, resp.StatusCode)
+			return false
+		} else {
+			log.Printf("[HTTP STATUS]: %s, Status Code: %d, Retry #%d\n", url, resp.StatusCode, i+1)
+			backoff = 5 * time.Second
+		}
+		if !sleepContext(ctx, backoff) {
+			return false
+		}
+	}
+
+	log.Printf("[URL UNREACHABLE]: %s\n", url)
+	return false
+}
+
+// sleepContext pauses for d, returning false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,177 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IPPresets are the named address ranges accepted by IPPreset.
+var IPPresets = map[string][]string{
+	// private is RFC 1918 and IPv6 unique local addresses.
+	"private": {"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"},
+	// loopback is the IPv4 and IPv6 loopback ranges.
+	"loopback": {"127.0.0.0/8", "::1/128"},
+	// metadata is the instance metadata endpoints of cloud providers.
+	"metadata": {"169.254.169.254/32", "fd00:ec2::254/128", "100.100.100.200/32"},
+	// hetzner is the list paxkk has always skipped, the ranges of the
+	// Hetzner hosting provider.
+	"hetzner": {
+		"5.9.0.0/16", "5.75.128.0/17", "5.161.0.0/21", "5.161.164.0/22", "5.161.168.0/21",
+		"5.161.238.0/23", "5.222.0.0/15", "23.88.0.0/17", "37.27.0.0/16", "45.145.227.0/24",
+		"46.4.0.0/16", "46.62.128.0/17", "49.12.0.0/15", "65.21.0.0/16", "65.108.0.0/15",
+		"77.42.0.0/17", "78.46.0.0/15", "78.138.62.0/24", "85.10.192.0/18", "88.99.0.0/16",
+		"88.198.0.0/16", "89.167.0.0/17", "91.99.0.0/16", "91.107.128.0/17", "91.190.240.0/21",
+		"91.233.8.0/22", "94.130.0.0/16", "95.216.0.0/15", "116.202.0.0/15", "128.140.0.0/17",
+		"135.181.0.0/16", "136.243.0.0/16", "138.199.128.0/17", "138.201.0.0/16", "142.132.128.0/17",
+		"144.76.0.0/16", "148.251.0.0/16", "157.90.0.0/16", "157.180.0.0/17", "159.69.0.0/16",
+		"162.55.0.0/16", "167.233.0.0/16", "167.235.0.0/16", "168.119.0.0/16", "171.25.225.0/24",
+		"176.9.0.0/16", "178.63.0.0/16", "178.212.75.0/24", "185.12.64.0/22", "185.50.120.0/23",
+		"185.107.52.0/22", "185.126.28.0/22", "185.157.83.0/24", "185.157.176.0/22", "185.171.224.0/22",
+		"185.189.228.0/22", "185.213.45.0/24", "185.216.237.0/24", "185.226.99.0/24", "185.228.8.0/23",
+		"185.242.76.0/24", "185.253.111.0/24", "188.34.128.0/17", "188.40.0.0/16", "188.245.0.0/16",
+		"193.25.170.0/23", "193.110.6.0/23", "193.163.198.0/24", "194.42.180.0/22", "194.42.184.0/22",
+		"194.62.106.0/24", "195.60.226.0/24", "195.201.0.0/16", "195.248.224.0/24", "197.242.84.0/22",
+		"201.131.3.0/24", "204.29.146.0/24", "213.133.96.0/19", "213.232.193.0/24", "213.239.192.0/18",
+		"216.55.108.0/22",
+	},
+}
+
+// IPPolicy decides which addresses the seeds may resolve to. A seed is
+// skipped if any address of its host is denied, by range or by AS number,
+// and not allowed. Allow ranges take precedence over the deny rules.
+type IPPolicy struct {
+	// Deny are the denied address ranges.
+	Deny []*net.IPNet
+	// Allow are address ranges crawled even when they are denied.
+	Allow []*net.IPNet
+	// DenyASNs are the denied AS numbers, looked up in ASNs.
+	DenyASNs []uint32
+	// ASNs maps addresses to AS numbers for DenyASNs.
+	ASNs *ASNDB
+}
+
+// DefaultIPPolicy returns the policy used when none is given: the hetzner
+// preset is denied.
+func DefaultIPPolicy() *IPPolicy {
+	deny, _ := IPPreset("hetzner")
+	return &IPPolicy{Deny: deny}
+}
+
+// IPPreset returns the ranges of the named IPPresets entry.
+func IPPreset(name string) ([]*net.IPNet, error) {
+	cidrs, ok := IPPresets[name]
+	if !ok {
+		names := make([]string, 0, len(IPPresets))
+		for name := range IPPresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown IP preset %q (want %s)", name, strings.Join(names, ", "))
+	}
+	return ParseCIDRs(cidrs)
+}
+
+// ParseCIDRs parses address ranges in CIDR notation. A single address is a
+// range of its own.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// LoadCIDRFile reads address ranges, one CIDR or address per line. Blank
+// lines and lines starting with # are ignored.
+func LoadCIDRFile(filename string) ([]*net.IPNet, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var nets []*net.IPNet
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parsed, err := ParseCIDRs([]string{line})
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNo, err)
+		}
+		nets = append(nets, parsed...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nets, nil
+}
+
+// ParseASNs parses a comma separated list of AS numbers, with or without
+// the AS prefix.
+func ParseASNs(list string) ([]uint32, error) {
+	var asns []uint32
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(field), "AS"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AS number %q", field)
+		}
+		asns = append(asns, uint32(n))
+	}
+	return asns, nil
+}
+
+// check returns why ip is denied, or "" if it is not. A nil policy denies
+// nothing.
+func (p *IPPolicy) check(ip net.IP) string {
+	if p == nil {
+		return ""
+	}
+	for _, ipNet := range p.Allow {
+		if ipNet.Contains(ip) {
+			return ""
+		}
+	}
+	for _, ipNet := range p.Deny {
+		if ipNet.Contains(ip) {
+			return ip.String() + " in " + ipNet.String()
+		}
+	}
+	if len(p.DenyASNs) != 0 && p.ASNs != nil {
+		if asn, ok := p.ASNs.Lookup(ip); ok {
+			for _, denied := range p.DenyASNs {
+				if asn == denied {
+					return ip.String() + " in AS" + strconv.FormatUint(uint64(asn), 10)
+				}
+			}
+		}
+	}
+	return ""
+}
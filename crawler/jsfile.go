@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// jsRootPathRegex matches root-relative paths such as "/api/v2/users".
+var jsRootPathRegex = regexp.MustCompile(`^/[A-Za-z0-9_\-.~%]+(?:/[A-Za-z0-9_\-.~%{}:]*)*(?:\?[^\s<>]*)?$`)
+
+// extractJSEndpoints returns the absolute URLs and root-relative paths
+// found in the literals of a JavaScript file, without duplicates. With
+// relative set the scored relative endpoints are merged in as well.
+func extractJSEndpoints(js string, relative bool) []jsEndpoint {
+	lits := jsLiterals(js)
+	urls := jsLiteralURLs(lits)
+	seen := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		seen[u] = true
+	}
+	for _, lit := range lits {
+		path := lit.Value
+		if seen[path] || !jsRootPathRegex.MatchString(path) || !strings.ContainsAny(path, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			continue
+		}
+		seen[path] = true
+		urls = append(urls, path)
+	}
+	var scored []jsEndpoint
+	if relative {
+		scored = jsLiteralRelative(lits)
+	}
+	return mergeJSEndpoints(urls, scored)
+}
+
+// isJSResponse reports whether r is a JavaScript file.
+func isJSResponse(r *colly.Response) bool {
+	contentType := r.Headers.Get("Content-Type")
+	if strings.Contains(contentType, "javascript") || strings.Contains(contentType, "ecmascript") {
+		return true
+	}
+	path := strings.ToLower(r.Request.URL.Path)
+	return strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".mjs")
+}
+
+// scriptPageKey is the colly context key holding the URL of the page that
+// loaded a script, for resolving the script's relative endpoints.
+const scriptPageKey = "page"
+
+// scriptDepthKey is the colly context key holding the depth reported for
+// results found in a script. Scripts are fetched outside of the crawl's
+// depth accounting, so their request depth is always 1.
+const scriptDepthKey = "depth"
+
+// scriptContext returns a colly context for fetching a script loaded by
+// page, whose results are reported at depth.
+func scriptContext(page string, depth int) *colly.Context {
+	ctx := colly.NewContext()
+	if page != "" {
+		ctx.Put(scriptPageKey, page)
+	}
+	ctx.Put(scriptDepthKey, strconv.Itoa(depth))
+	return ctx
+}
+
+// resultDepth returns the depth reported for results found in the response
+// to req: the depth stored by scriptContext, or else the request depth.
+func resultDepth(req *colly.Request) int {
+	if depth, err := strconv.Atoi(req.Ctx.Get(scriptDepthKey)); err == nil {
+		return depth
+	}
+	return req.Depth
+}
+
+// resolveJSEndpoint resolves an endpoint found in the script served by r.
+// Relative paths, "/api/users" as well as "api/users", are relative to the
+// page that loaded the script rather than to the script, as in a browser.
+// URLs with a scheme or a //host are returned unchanged, as are endpoints
+// of scripts whose page isn't known.
+func resolveJSEndpoint(r *colly.Response, endpoint string) string {
+	if strings.HasPrefix(endpoint, "//") {
+		return endpoint
+	}
+	page, err := url.Parse(r.Ctx.Get(scriptPageKey))
+	if err != nil || page.Host == "" {
+		return endpoint
+	}
+	u, err := page.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.String()
+}
+
+// registerJSFileExtractors fetches the external scripts of every page,
+// regardless of the crawl depth, and reports the endpoints found in them
+// with the script as Where. Scope and size limits of the collector apply.
+// Relative endpoints are resolved against the page that loaded the script,
+// and results are reported one level deeper than that page.
+func (cr *Crawler) registerJSFileExtractors(c *colly.Collector, s *seedCrawl) {
+	c.OnHTML("script[src]", func(e *colly.HTMLElement) {
+		if src := e.Request.AbsoluteURL(e.Attr("src")); src != "" {
+			c.Request("GET", src, nil, scriptContext(e.Request.URL.String(), e.Request.Depth+1), nil)
+		}
+	})
+
+	c.OnResponse(func(r *colly.Response) {
+		if !isJSResponse(r) {
+			return
+		}
+		for _, ep := range extractJSEndpoints(string(r.Body), cr.cfg.JSRelative) {
+			cr.emitFrom(s, r.Request, r, resolveJSEndpoint(r, ep.URL), Result{Source: "js-file", Confidence: ep.Confidence})
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newScriptServers starts a site whose /app/ page loads /js/app.js from a
+// second server, as with a CDN. files are served by the CDN.
+func newScriptServers(t *testing.T, files map[string]string) (site, cdn *httptest.Server) {
+	t.Helper()
+	cdn = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(cdn.Close)
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><script src="%s/js/app.js"></script></body></html>`, cdn.URL)
+	}))
+	t.Cleanup(site.Close)
+	return site, cdn
+}
+
+func TestJSFileEndpointsResolveAgainstPage(t *testing.T) {
+	site, cdn := newScriptServers(t, map[string]string{
+		"/js/app.js": `fetch("/api/users"); fetch("v1/orders"); fetch("https://api.example.com/v2/x"); fetch("//static.example.com/img.png")`,
+	})
+	cfg := testConfig()
+	cfg.JSRelative = true
+	results := crawl(t, cfg, site.URL+"/app/")
+
+	for _, want := range []string{
+		site.URL + "/api/users",
+		site.URL + "/app/v1/orders",
+		"https://api.example.com/v2/x",
+	} {
+		res, ok := findResult(results, "js-file", want)
+		if !ok {
+			t.Errorf("js-file %s not reported", want)
+			continue
+		}
+		if res.Where != cdn.URL+"/js/app.js" {
+			t.Errorf("js-file %s found in %s, want the script", want, res.Where)
+		}
+	}
+	for _, res := range results {
+		if res.Source == "js-file" && res.URL == cdn.URL+"/api/users" {
+			t.Errorf("endpoint resolved against the script: %s", res.URL)
+		}
+	}
+}
+
+func TestScriptResultsReportPageDepth(t *testing.T) {
+	site, cdn := newScriptServers(t, map[string]string{
+		"/js/app.js":     "fetch(\"/api/users\")\n//# sourceMappingURL=app.js.map\n",
+		"/js/app.js.map": `{"version":3,"sources":["src/a.js"],"sourcesContent":["fetch(\"/api/secret\")"]}`,
+	})
+	seed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><a href="%s/app/">app</a></body></html>`, site.URL)
+	}))
+	t.Cleanup(seed.Close)
+	cfg := testConfig()
+	cfg.Depth = 3
+	results := crawl(t, cfg, seed.URL+"/")
+
+	// The script is loaded by the depth 2 page, so what is found in it and
+	// in its source map is one level deeper.
+	for _, want := range []struct{ source, url string }{
+		{"js-file", site.URL + "/api/users"},
+		{"sourcemap", cdn.URL + "/js/app.js.map"},
+		{"sourcemap-source", site.URL + "/api/secret"},
+	} {
+		res, ok := findResult(results, want.source, want.url)
+		if !ok {
+			t.Errorf("%s %s not reported", want.source, want.url)
+			continue
+		}
+		if res.Depth != 3 {
+			t.Errorf("%s %s reported at depth %d, want 3", want.source, want.url, res.Depth)
+		}
+	}
+}
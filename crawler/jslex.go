@@ -0,0 +1,398 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsTokKind is the kind of a JavaScript token.
+type jsTokKind int
+
+const (
+	tokPunct jsTokKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokTemplate
+	tokRegex
+)
+
+// jsTok is a JavaScript token. For string and template literals text is
+// the cooked value.
+type jsTok struct {
+	kind jsTokKind
+	text string
+	// partial is set for a template literal cut at a ${} substitution.
+	partial bool
+}
+
+// jsKeywordsBeforeExpr are the keywords after which a / starts a regular
+// expression rather than a division.
+var jsKeywordsBeforeExpr = map[string]bool{
+	"return": true, "typeof": true, "case": true, "do": true, "else": true,
+	"in": true, "instanceof": true, "new": true, "delete": true, "void": true,
+	"throw": true, "yield": true, "await": true, "of": true,
+}
+
+// jsBrace is an open { of the lexer. Substitutions of template literals
+// are tracked as braces so the template resumes at the matching }.
+type jsBrace struct {
+	template bool
+	// emitted is set once a chunk of the template has been emitted.
+	emitted bool
+}
+
+// jsLexer is a lightweight JavaScript tokenizer. It understands comments,
+// string, template and regular expression literals well enough to pull the
+// literals out of minified bundles; it does not validate the syntax.
+type jsLexer struct {
+	src    string
+	pos    int
+	toks   []jsTok
+	braces []jsBrace
+}
+
+// lexJS tokenizes src. Comments and whitespace are dropped.
+func lexJS(src string) []jsTok {
+	l := &jsLexer{src: src}
+	for l.pos < len(l.src) {
+		l.next()
+	}
+	return l.toks
+}
+
+func (l *jsLexer) next() {
+	c := l.src[l.pos]
+	switch {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+		l.pos++
+	case strings.HasPrefix(l.src[l.pos:], "//"):
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end < 0 {
+			l.pos = len(l.src)
+		} else {
+			l.pos += end + 1
+		}
+	case strings.HasPrefix(l.src[l.pos:], "/*"):
+		end := strings.Index(l.src[l.pos+2:], "*/")
+		if end < 0 {
+			l.pos = len(l.src)
+		} else {
+			l.pos += end + 4
+		}
+	case c == '"' || c == '\'':
+		l.toks = append(l.toks, jsTok{kind: tokString, text: l.quoted(c)})
+	case c == '`':
+		l.pos++
+		l.template(false, false)
+	case c == '/' && l.regexAllowed():
+		l.regex()
+	case c == '{':
+		l.braces = append(l.braces, jsBrace{})
+		l.punct(1)
+	case c == '}':
+		if n := len(l.braces); n > 0 {
+			b := l.braces[n-1]
+			l.braces = l.braces[:n-1]
+			if b.template {
+				l.pos++
+				l.template(true, b.emitted)
+				return
+			}
+		}
+		l.punct(1)
+	case c == '+' || c == '-':
+		if l.pos+1 < len(l.src) && (l.src[l.pos+1] == c || l.src[l.pos+1] == '=') {
+			l.punct(2)
+		} else {
+			l.punct(1)
+		}
+	case c >= '0' && c <= '9' || c == '.' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9':
+		start := l.pos
+		for l.pos < len(l.src) && (isJSIdentByte(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		l.toks = append(l.toks, jsTok{kind: tokNumber, text: l.src[start:l.pos]})
+	case isJSIdentByte(c):
+		start := l.pos
+		for l.pos < len(l.src) && isJSIdentByte(l.src[l.pos]) {
+			l.pos++
+		}
+		l.toks = append(l.toks, jsTok{kind: tokIdent, text: l.src[start:l.pos]})
+	default:
+		l.punct(1)
+	}
+}
+
+func (l *jsLexer) punct(n int) {
+	l.toks = append(l.toks, jsTok{kind: tokPunct, text: l.src[l.pos : l.pos+n]})
+	l.pos += n
+}
+
+// isJSIdentByte reports whether c can be part of an identifier. Non-ASCII
+// bytes are accepted so Unicode identifiers stay in one token.
+func isJSIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf
+}
+
+// regexAllowed reports whether a / at the current position starts a
+// regular expression literal rather than a division, based on the previous
+// token.
+func (l *jsLexer) regexAllowed() bool {
+	if len(l.toks) == 0 {
+		return true
+	}
+	prev := l.toks[len(l.toks)-1]
+	switch prev.kind {
+	case tokPunct:
+		return prev.text != ")" && prev.text != "]" && prev.text != "}"
+	case tokIdent:
+		return jsKeywordsBeforeExpr[prev.text]
+	}
+	return false
+}
+
+// regex reads a regular expression literal. If the line ends before the
+// closing slash it wasn't one, and the slash is taken as a punctuator.
+func (l *jsLexer) regex() {
+	inClass := false
+	for i := l.pos + 1; i < len(l.src); i++ {
+		switch c := l.src[i]; {
+		case c == '\n' || c == '\r':
+			l.punct(1)
+			return
+		case c == '\\':
+			i++
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			end := i + 1
+			for end < len(l.src) && isJSIdentByte(l.src[end]) {
+				end++
+			}
+			l.toks = append(l.toks, jsTok{kind: tokRegex, text: l.src[l.pos:end]})
+			l.pos = end
+			return
+		}
+	}
+	l.punct(1)
+}
+
+// quoted reads a string literal delimited by quote, returning its cooked
+// value. An unterminated string ends at the line break.
+func (l *jsLexer) quoted(quote byte) string {
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == quote:
+			l.pos++
+			return b.String()
+		case c == '\n':
+			return b.String()
+		case c == '\\':
+			l.escape(&b)
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return b.String()
+}
+
+// template reads a template literal chunk, from after the opening backtick
+// or the } ending a substitution, up to the closing backtick or the next
+// ${. Only the first non-empty chunk of a template is emitted, marked
+// partial if the template has substitutions.
+func (l *jsLexer) template(resumed, emitted bool) {
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '`':
+			l.pos++
+			if !emitted && (b.Len() > 0 || !resumed) {
+				l.toks = append(l.toks, jsTok{kind: tokTemplate, text: b.String(), partial: resumed})
+			}
+			return
+		case c == '$' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '{':
+			l.pos += 2
+			if !emitted && b.Len() > 0 {
+				l.toks = append(l.toks, jsTok{kind: tokTemplate, text: b.String(), partial: true})
+				emitted = true
+			}
+			l.braces = append(l.braces, jsBrace{template: true, emitted: emitted})
+			return
+		case c == '\\':
+			l.escape(&b)
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	if !emitted && b.Len() > 0 {
+		l.toks = append(l.toks, jsTok{kind: tokTemplate, text: b.String(), partial: resumed})
+	}
+}
+
+// escape decodes the escape sequence at the current position into b.
+func (l *jsLexer) escape(b *strings.Builder) {
+	l.pos++ // backslash
+	if l.pos >= len(l.src) {
+		return
+	}
+	c := l.src[l.pos]
+	l.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'v':
+		b.WriteByte('\v')
+	case '0':
+		b.WriteByte(0)
+	case '\r':
+		// line continuation
+		if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+			l.pos++
+		}
+	case '\n':
+		// line continuation
+	case 'x':
+		if r, ok := l.hex(2); ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('x')
+		}
+	case 'u':
+		if l.pos < len(l.src) && l.src[l.pos] == '{' {
+			if end := strings.IndexByte(l.src[l.pos:], '}'); end > 1 {
+				if v, err := strconv.ParseUint(l.src[l.pos+1:l.pos+end], 16, 32); err == nil {
+					b.WriteRune(rune(v))
+					l.pos += end + 1
+					return
+				}
+			}
+			b.WriteByte('u')
+		} else if r, ok := l.hex(4); ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('u')
+		}
+	default:
+		b.WriteByte(c)
+	}
+}
+
+// hex reads n hex digits as a rune.
+func (l *jsLexer) hex(n int) (rune, bool) {
+	if l.pos+n > len(l.src) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(l.src[l.pos:l.pos+n], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	l.pos += n
+	return rune(v), true
+}
+
+// jsLiteral is a string or template literal of a script, with "a" + "b"
+// concatenations folded into one value.
+type jsLiteral struct {
+	Value string
+	// Callee is the function the literal is an argument of, such as
+	// "fetch" or "axios.get", and Arg the index of that argument.
+	Callee string
+	Arg    int
+	// Partial is set when Value is only part of the runtime string: a
+	// template cut at a substitution, or a literal concatenated with a
+	// variable.
+	Partial bool
+}
+
+// jsFrame is an open bracket while walking the tokens.
+type jsFrame struct {
+	paren  bool
+	callee string
+	arg    int
+}
+
+// jsLiterals returns the string and template literals of a script in
+// source order.
+func jsLiterals(src string) []jsLiteral {
+	toks := lexJS(src)
+	isLiteral := func(i int) bool {
+		return i < len(toks) && (toks[i].kind == tokString || toks[i].kind == tokTemplate)
+	}
+	isPlus := func(i int) bool {
+		return i >= 0 && i < len(toks) && toks[i].kind == tokPunct && toks[i].text == "+"
+	}
+
+	var lits []jsLiteral
+	var frames []jsFrame
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.kind == tokPunct:
+			switch t.text {
+			case "(":
+				frames = append(frames, jsFrame{paren: true, callee: jsCallee(toks, i-1)})
+			case "[", "{":
+				frames = append(frames, jsFrame{})
+			case ")", "]", "}":
+				if len(frames) > 0 {
+					frames = frames[:len(frames)-1]
+				}
+			case ",":
+				if n := len(frames); n > 0 && frames[n-1].paren {
+					frames[n-1].arg++
+				}
+			}
+		case isLiteral(i):
+			start := i
+			lit := jsLiteral{Value: t.text}
+			// A template cut at a substitution can't be extended
+			cut := t.partial
+			for !cut && isPlus(i+1) && isLiteral(i+2) {
+				lit.Value += toks[i+2].text
+				cut = toks[i+2].partial
+				i += 2
+			}
+			lit.Partial = cut || isPlus(start-1) || isPlus(i+1)
+			if n := len(frames); n > 0 && frames[n-1].paren {
+				lit.Callee, lit.Arg = frames[n-1].callee, frames[n-1].arg
+			}
+			lits = append(lits, lit)
+		}
+	}
+	return lits
+}
+
+// jsCallee returns the dotted name ending at token i, such as "xhr.open",
+// or "" if there is none.
+func jsCallee(toks []jsTok, i int) string {
+	var parts []string
+	for i >= 0 && toks[i].kind == tokIdent {
+		parts = append(parts, toks[i].text)
+		if i < 2 || toks[i-1].kind != tokPunct || toks[i-1].text != "." {
+			break
+		}
+		i -= 2
+	}
+	for l, r := 0, len(parts)-1; l < r; l, r = l+1, r-1 {
+		parts[l], parts[r] = parts[r], parts[l]
+	}
+	return strings.Join(parts, ".")
+}
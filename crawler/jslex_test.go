@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexJSRegexOrDivision(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		regex []string
+	}{
+		{"start of script", `/ab+c/g.test(s)`, []string{"/ab+c/g"}},
+		{"after assignment", `x = /a\/b/i`, []string{`/a\/b/i`}},
+		{"after paren call", `f(x) / 2 / y`, nil},
+		{"after open paren", `s.match(/[/]x/)`, []string{"/[/]x/"}},
+		{"after bracket", `a[0] / 2 / i`, nil},
+		{"after identifier", `total / count / 2`, nil},
+		{"after number", `10 / 2 / 5`, nil},
+		{"after return", `return /^\d+$/.test(v)`, []string{`/^\d+$/`}},
+		{"after typeof", `typeof /x/`, []string{"/x/"}},
+		{"after comma", `f(a, /b/)`, []string{"/b/"}},
+		{"line ends first", "a = b\n/ c", nil},
+		{"comment", `a // b / c /`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var regex []string
+			for _, tok := range lexJS(tt.src) {
+				if tok.kind == tokRegex {
+					regex = append(regex, tok.text)
+				}
+			}
+			if !reflect.DeepEqual(regex, tt.regex) {
+				t.Errorf("lexJS(%q) regexes = %q, want %q", tt.src, regex, tt.regex)
+			}
+		})
+	}
+}
+
+func TestLexJSLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []jsTok
+	}{
+		{"double quoted", `"/api/users"`, []jsTok{{kind: tokString, text: "/api/users"}}},
+		{"single quoted", `'/api/users'`, []jsTok{{kind: tokString, text: "/api/users"}}},
+		{"escaped quote", `'it\'s'`, []jsTok{{kind: tokString, text: "it's"}}},
+		{"hex and unicode escapes", `"\x2fapi/v\u{31}"`, []jsTok{{kind: tokString, text: "/api/v1"}}},
+		{"control escapes", `"a\tb\nc"`, []jsTok{{kind: tokString, text: "a\tb\nc"}}},
+		{"invalid hex escape", `"\xZZ"`, []jsTok{{kind: tokString, text: "xZZ"}}},
+		{"line continuation", "\"/api/\\\nusers\"", []jsTok{{kind: tokString, text: "/api/users"}}},
+		{"unterminated at line break", "\"/api\nx", []jsTok{{kind: tokString, text: "/api"}, {kind: tokIdent, text: "x"}}},
+		{"plain template", "`/api/users`", []jsTok{{kind: tokTemplate, text: "/api/users"}}},
+		{"template substitution", "`/api/${id}/items`", []jsTok{
+			{kind: tokTemplate, text: "/api/", partial: true},
+			{kind: tokIdent, text: "id"},
+		}},
+		{"template starting with substitution", "`${base}/items`", []jsTok{
+			{kind: tokIdent, text: "base"},
+			{kind: tokTemplate, text: "/items", partial: true},
+		}},
+		{"nested template", "`/a/${b ? `/c/${d}` : '/e'}/f`", []jsTok{
+			{kind: tokTemplate, text: "/a/", partial: true},
+			{kind: tokIdent, text: "b"},
+			{kind: tokPunct, text: "?"},
+			{kind: tokTemplate, text: "/c/", partial: true},
+			{kind: tokIdent, text: "d"},
+			{kind: tokPunct, text: ":"},
+			{kind: tokString, text: "/e"},
+		}},
+		{"object in substitution", "`/a/${ {k: 1}.k }`", []jsTok{
+			{kind: tokTemplate, text: "/a/", partial: true},
+			{kind: tokPunct, text: "{"},
+			{kind: tokIdent, text: "k"},
+			{kind: tokPunct, text: ":"},
+			{kind: tokNumber, text: "1"},
+			{kind: tokPunct, text: "}"},
+			{kind: tokPunct, text: "."},
+			{kind: tokIdent, text: "k"},
+		}},
+		{"template escape", "`\\`/a\\${b}`", []jsTok{{kind: tokTemplate, text: "`/a${b}"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lexJS(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lexJS(%q) = %+v, want %+v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []jsLiteral
+	}{
+		{"call argument", `fetch("/api/users")`, []jsLiteral{{Value: "/api/users", Callee: "fetch"}}},
+		{"method argument index", `xhr.open("POST", "/save")`, []jsLiteral{
+			{Value: "POST", Callee: "xhr.open"},
+			{Value: "/save", Callee: "xhr.open", Arg: 1},
+		}},
+		{"folded concatenation", `fetch("/api/" + "v1/" + 'users')`, []jsLiteral{{Value: "/api/v1/users", Callee: "fetch"}}},
+		{"concatenated with variable", `u = "/api/users/" + id`, []jsLiteral{{Value: "/api/users/", Partial: true}}},
+		{"variable prefix", `u = base + "/users"`, []jsLiteral{{Value: "/users", Partial: true}}},
+		{"folded then variable", `"/a/" + "b/" + id`, []jsLiteral{{Value: "/a/b/", Partial: true}}},
+		{"template cut", "`/a/${x}` + \"/b\"", []jsLiteral{
+			{Value: "/a/", Partial: true},
+			{Value: "/b", Partial: true},
+		}},
+		{"template folded", "\"/a/\" + `b`", []jsLiteral{{Value: "/a/b"}}},
+		{"nested calls", `get(url("/x"), "/y")`, []jsLiteral{
+			{Value: "/x", Callee: "url"},
+			{Value: "/y", Callee: "get", Arg: 1},
+		}},
+		{"array element", `f(["/a", "/b"])`, []jsLiteral{{Value: "/a"}, {Value: "/b"}}},
+		{"regex is not a literal", `s.replace(/"/g, "'")`, []jsLiteral{{Value: "'", Callee: "s.replace", Arg: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsLiterals(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jsLiterals(%q) = %+v, want %+v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSLiteralsUnterminated(t *testing.T) {
+	inputs := []string{
+		`"abc`,
+		`'abc\`,
+		"`abc",
+		"`abc ${",
+		"`${`${",
+		"`a${b}",
+		"}}}`",
+		"/*",
+		"a = /[/",
+		"a = /abc",
+		`"\x4`,
+		`"\u00`,
+		`"\u{41`,
+		`"\u{}"`,
+		`fetch(("/a" +`,
+		`+ "a" +`,
+		".5",
+		"\\",
+	}
+	for _, src := range inputs {
+		jsLiterals(src)
+	}
+}
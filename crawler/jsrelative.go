@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// jsEndpoint is a URL or path found in JavaScript, with a confidence
+// between 0 and 1 that it is a real endpoint.
+type jsEndpoint struct {
+	URL        string
+	Confidence float64
+}
+
+// jsRelativePattern is one way a literal can look like a relative
+// endpoint.
+type jsRelativePattern struct {
+	Regex      *regexp.Regexp
+	Confidence float64
+}
+
+// jsRelativePatterns are matched against whole literals in order of
+// decreasing confidence; the first match wins.
+var jsRelativePatterns = []jsRelativePattern{
+	// "/path", "./path", "../path"
+	{regexp.MustCompile(`^(?:/|\.\./|\./)[^><,;| *()(%$^/\\\[\]][^><,;|()\s]+$`), 0.7},
+	// "dir/file.php", "api/users.json?x=1"
+	{regexp.MustCompile(`^[A-Za-z0-9_\-/]+/[A-Za-z0-9_\-/]+\.(?:[A-Za-z]{1,4}|action)(?:[?#]\S*)?$`), 0.6},
+	// "file.php", "users.json"
+	{regexp.MustCompile(`^[A-Za-z0-9_\-]+\.(?:php|asp|aspx|jsp|json|action|html|js|txt|xml)(?:[?#]\S*)?$`), 0.5},
+	// "api/users", "v1/orders"
+	{regexp.MustCompile(`^[A-Za-z0-9_\-]+/[A-Za-z0-9_\-/]{3,}(?:[?#]\S*)?$`), 0.4},
+}
+
+// jsPartialPathRegex matches the known prefix of a path built at runtime,
+// as in '/api/users/' + id or `api/${version}/orders`.
+var jsPartialPathRegex = regexp.MustCompile(`^(?:/|\.\.?/|[A-Za-z0-9_\-]+/)\S*$`)
+
+// jsRequestCallees are the functions whose first argument is a URL.
+var jsRequestCallees = map[string]bool{
+	"fetch": true, "axios": true, "axios.get": true, "axios.post": true, "axios.put": true,
+	"axios.patch": true, "axios.delete": true, "axios.head": true, "axios.options": true,
+	"axios.request": true, "$.get": true, "$.post": true, "$.ajax": true, "$.getJSON": true,
+}
+
+// jsRelativeNoise are prefixes of strings that look like paths but are not,
+// such as MIME types.
+var jsRelativeNoise = []string{
+	"application/", "text/", "image/", "audio/", "video/", "font/", "multipart/", "model/",
+}
+
+// jsDateRegex matches dates and date formats such as "MM/DD/YYYY".
+var jsDateRegex = regexp.MustCompile(`^(?:\d{1,4}|[MDYmdy]{1,4})/(?:\d{1,2}|[MDYmdy]{1,4})/(?:\d{1,4}|[MDYmdy]{1,4})$`)
+
+// extractJSRelative returns the relative paths and API routes found in
+// JavaScript, LinkFinder-style, each with a confidence score.
+func extractJSRelative(js string) []jsEndpoint {
+	return jsLiteralRelative(jsLiterals(js))
+}
+
+// jsLiteralRelative scores the literals of a script that look like
+// relative endpoints. A path seen several times keeps its highest
+// confidence.
+func jsLiteralRelative(lits []jsLiteral) []jsEndpoint {
+	var endpoints []jsEndpoint
+	index := make(map[string]int)
+	for _, lit := range lits {
+		path := lit.Value
+		if !isJSRelativeCandidate(path) {
+			continue
+		}
+		confidence := jsRelativeConfidence(lit)
+		if confidence == 0 {
+			continue
+		}
+		if i, ok := index[path]; ok {
+			if confidence > endpoints[i].Confidence {
+				endpoints[i].Confidence = confidence
+			}
+			continue
+		}
+		index[path] = len(endpoints)
+		endpoints = append(endpoints, jsEndpoint{URL: path, Confidence: confidence})
+	}
+	return endpoints
+}
+
+// jsRelativeConfidence returns how likely lit is a relative endpoint, or 0
+// if it doesn't look like one.
+func jsRelativeConfidence(lit jsLiteral) float64 {
+	if strings.ContainsAny(lit.Value, " \t\r\n") {
+		return 0
+	}
+	// fetch("/x"), axios.get('/x'), xhr.open("GET", "/x")
+	if lit.Arg == 0 && jsRequestCallees[lit.Callee] || lit.Arg == 1 && strings.HasSuffix(lit.Callee, ".open") {
+		return 0.9
+	}
+	if lit.Partial && jsPartialPathRegex.MatchString(lit.Value) {
+		return 0.7
+	}
+	for _, p := range jsRelativePatterns {
+		if p.Regex.MatchString(lit.Value) {
+			return p.Confidence
+		}
+	}
+	return 0
+}
+
+// isJSRelativeCandidate filters out strings that can't be relative
+// endpoints.
+func isJSRelativeCandidate(path string) bool {
+	if path == "" || path == "/" || strings.HasPrefix(path, "//") || strings.Contains(path, "://") {
+		return false
+	}
+	lower := strings.ToLower(path)
+	for _, noise := range jsRelativeNoise {
+		if strings.HasPrefix(lower, noise) {
+			return false
+		}
+	}
+	return !jsDateRegex.MatchString(path)
+}
+
+// mergeJSEndpoints combines plain extractor URLs with scored relative
+// endpoints, keeping the first occurrence of each.
+func mergeJSEndpoints(urls []string, relative []jsEndpoint) []jsEndpoint {
+	confidence := make(map[string]float64, len(relative))
+	for _, ep := range relative {
+		confidence[ep.URL] = ep.Confidence
+	}
+	seen := make(map[string]bool, len(urls)+len(relative))
+	endpoints := make([]jsEndpoint, 0, len(urls)+len(relative))
+	for _, u := range urls {
+		if !seen[u] {
+			seen[u] = true
+			endpoints = append(endpoints, jsEndpoint{URL: u, Confidence: confidence[u]})
+		}
+	}
+	for _, ep := range relative {
+		if !seen[ep.URL] {
+			seen[ep.URL] = true
+			endpoints = append(endpoints, ep)
+		}
+	}
+	return endpoints
+}
+
+// registerJSRelativeExtractors reports relative endpoints of inline
+// scripts, resolved against the page.
+func (cr *Crawler) registerJSRelativeExtractors(c *colly.Collector, s *seedCrawl) {
+	c.OnHTML("script", func(e *colly.HTMLElement) {
+		for _, ep := range extractJSRelative(e.Text) {
+			cr.emitFrom(s, e.Request, e.Response, ep.URL, Result{Source: "jscode", Tag: e.Name, Confidence: ep.Confidence})
+		}
+	})
+}
@@ -0,0 +1,412 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// maxLoginFailures is the number of consecutive failed logins after which
+// re-login attempts stop.
+const maxLoginFailures = 3
+
+// LoginSpec describes a form based login performed before crawling, e.g.
+//
+//	url: https://target.com/login
+//	form: "form#login"
+//	fields:
+//	  username: alice
+//	  password: s3cret
+//	success:
+//	  redirect: /dashboard
+//	relogin:
+//	  redirect: /login
+//
+// The login page at URL is fetched first so hidden inputs such as CSRF
+// tokens and session cookies are picked up, then the form is submitted
+// with Fields filled in. The session cookies are kept in Config.Jar.
+type LoginSpec struct {
+	// URL is the page holding the login form.
+	URL string `yaml:"url"`
+	// Form is a CSS selector for the login form. Defaults to the first form
+	// with a password input, or the first form on the page.
+	Form string `yaml:"form"`
+	// Action overrides the form action URL.
+	Action string `yaml:"action"`
+	// Method overrides the form method.
+	Method string `yaml:"method"`
+	// Fields are the form values to submit, by input name.
+	Fields map[string]string `yaml:"fields"`
+	// Success must match the login response. When empty, any final
+	// status below 400 is a success.
+	Success LoginCondition `yaml:"success"`
+	// Relogin, when it matches a crawled response, triggers a new login and
+	// a retry of the request.
+	Relogin LoginCondition `yaml:"relogin"`
+}
+
+// LoginCondition matches an HTTP response. Every non-empty field must
+// match.
+type LoginCondition struct {
+	// Status is the response status code. For Success it is the status
+	// before any redirect is followed.
+	Status int `yaml:"status"`
+	// Redirect must be contained in one of the URLs redirected to.
+	Redirect string `yaml:"redirect"`
+	// Body is a regular expression matched against the final body.
+	Body string `yaml:"body"`
+
+	body *regexp.Regexp
+}
+
+// empty reports whether the condition has no criteria.
+func (lc *LoginCondition) empty() bool {
+	return lc.Status == 0 && lc.Redirect == "" && lc.Body == ""
+}
+
+// matches checks the condition against a response. status is the status
+// before redirects, redirects the URLs redirected to, body the final body.
+func (lc *LoginCondition) matches(status int, redirects []string, body []byte) bool {
+	if lc.empty() {
+		return false
+	}
+	if lc.Status != 0 && lc.Status != status {
+		return false
+	}
+	if lc.Redirect != "" {
+		found := false
+		for _, u := range redirects {
+			if strings.Contains(u, lc.Redirect) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if lc.body != nil && !lc.body.Match(body) {
+		return false
+	}
+	return true
+}
+
+// LoadLoginSpec reads a LoginSpec from a YAML file.
+func LoadLoginSpec(filename string) (*LoginSpec, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	spec := &LoginSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if err := spec.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return spec, nil
+}
+
+// compile validates the spec and compiles its regular expressions.
+func (spec *LoginSpec) compile() error {
+	if spec.URL == "" {
+		return errors.New("login url is required")
+	}
+	for _, lc := range []*LoginCondition{&spec.Success, &spec.Relogin} {
+		if lc.Body == "" {
+			continue
+		}
+		re, err := regexp.Compile(lc.Body)
+		if err != nil {
+			return err
+		}
+		lc.body = re
+	}
+	return nil
+}
+
+// loginSession serialises logins and counts failures.
+type loginSession struct {
+	mu       sync.Mutex
+	last     time.Time
+	failures int
+}
+
+// Login performs the form login of Config.Login. Run calls it before the
+// first seed; it is exported so callers can check for errors up front.
+func (cr *Crawler) Login(ctx context.Context) error {
+	if cr.cfg.Login == nil {
+		return nil
+	}
+	cr.login.mu.Lock()
+	defer cr.login.mu.Unlock()
+	return cr.doLogin(ctx)
+}
+
+// ensureLogin logs in unless a login already succeeded.
+func (cr *Crawler) ensureLogin(ctx context.Context) error {
+	if cr.cfg.Login == nil {
+		return nil
+	}
+	cr.login.mu.Lock()
+	defer cr.login.mu.Unlock()
+	if !cr.login.last.IsZero() {
+		return nil
+	}
+	return cr.doLogin(ctx)
+}
+
+// relogin logs in again after the response to a request sent at sent
+// matched LoginSpec.Relogin, and reports whether there is a session newer
+// than the request to retry it with. A login completed since the request
+// was sent is reused, so a burst of expired requests triggers a single
+// login; otherwise logins are at least a few seconds apart.
+func (cr *Crawler) relogin(ctx context.Context, sent time.Time) (bool, error) {
+	cr.login.mu.Lock()
+	defer cr.login.mu.Unlock()
+	if cr.login.last.After(sent) {
+		return true, nil
+	}
+	if cr.login.failures >= maxLoginFailures {
+		return false, errors.New("too many failed logins")
+	}
+	if time.Since(cr.login.last) < 5*time.Second {
+		return false, nil
+	}
+	log.Println("[relogin] session expired, logging in again")
+	if err := cr.doLogin(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// doLogin submits the login form. The caller holds cr.login.mu.
+func (cr *Crawler) doLogin(ctx context.Context) error {
+	spec := cr.cfg.Login
+	err := cr.submitLogin(ctx, spec)
+	if err != nil {
+		cr.login.failures++
+		return fmt.Errorf("login failed: %v", err)
+	}
+	cr.login.failures = 0
+	cr.login.last = time.Now()
+	return nil
+}
+
+func (cr *Crawler) submitLogin(ctx context.Context, spec *LoginSpec) error {
+	loginURL, err := url.Parse(spec.URL)
+	if err != nil {
+		return err
+	}
+
+	// Fetch the login page for hidden fields and pre-session cookies
+	values := url.Values{}
+	action, method := loginURL, http.MethodPost
+	resp, body, err := cr.loginPage(ctx, loginURL.String())
+	if err != nil {
+		return err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err == nil {
+		form := findLoginForm(doc, spec.Form)
+		if form.Length() > 0 {
+			if a, ok := form.Attr("action"); ok && a != "" {
+				if u, err := resp.Request.URL.Parse(a); err == nil {
+					action = u
+				}
+			}
+			if m, ok := form.Attr("method"); ok && m != "" {
+				method = strings.ToUpper(m)
+			}
+			form.Find("input[name], select[name], textarea[name]").Each(func(_ int, field *goquery.Selection) {
+				name, _ := field.Attr("name")
+				value, _ := field.Attr("value")
+				values.Set(name, value)
+			})
+		}
+	}
+
+	if spec.Action != "" {
+		if action, err = loginURL.Parse(spec.Action); err != nil {
+			return err
+		}
+	}
+	if spec.Method != "" {
+		method = strings.ToUpper(spec.Method)
+	}
+	for name, value := range spec.Fields {
+		values.Set(name, value)
+	}
+
+	// Submit the form, recording the redirect chain
+	var redirects []string
+	var firstStatus int
+	client := cr.loginClient(func(req *http.Request, via []*http.Request) error {
+		if firstStatus == 0 && req.Response != nil {
+			firstStatus = req.Response.StatusCode
+		}
+		redirects = append(redirects, req.URL.String())
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	})
+	var req *http.Request
+	if method == http.MethodGet {
+		u := *action
+		u.RawQuery = values.Encode()
+		req, err = http.NewRequestWithContext(ctx, method, u.String(), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, action.String(), strings.NewReader(values.Encode()))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	cr.setLoginHeaders(req)
+	resp, err = client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if firstStatus == 0 {
+		firstStatus = resp.StatusCode
+	}
+
+	if spec.Success.empty() {
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%s returned status %d", action, resp.StatusCode)
+		}
+		return nil
+	}
+	if !spec.Success.matches(firstStatus, redirects, body) {
+		return fmt.Errorf("%s: success condition not met (status %d)", action, firstStatus)
+	}
+	return nil
+}
+
+// findLoginForm picks the login form on the page.
+func findLoginForm(doc *goquery.Document, selector string) *goquery.Selection {
+	if selector != "" {
+		return doc.Find(selector).First()
+	}
+	if form := doc.Find("form:has(input[type=password])").First(); form.Length() > 0 {
+		return form
+	}
+	return doc.Find("form").First()
+}
+
+// loginPage fetches the login form page and reads its body.
+func (cr *Crawler) loginPage(ctx context.Context, u string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	cr.setLoginHeaders(req)
+	resp, err := cr.loginClient(nil).Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp, data, err
+}
+
+// loginClient returns an HTTP client sharing the crawl's transport and jar.
+func (cr *Crawler) loginClient(checkRedirect func(*http.Request, []*http.Request) error) *http.Client {
+	return &http.Client{
+		Transport:     cr.transport,
+		Jar:           cr.cfg.Jar,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+func (cr *Crawler) setLoginHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", cr.cfg.UserAgent)
+	for header, value := range cr.headersFor(req.URL.Hostname()) {
+		req.Header.Set(header, value)
+	}
+	applyHostHeader(req)
+}
+
+// loginOrigin is a request as it was sent, before colly replaced its URL
+// with the one it was redirected to.
+type loginOrigin struct {
+	url    *url.URL
+	method string
+	body   []byte
+	sent   time.Time
+}
+
+// registerRelogin retries requests whose response matches LoginSpec.Relogin
+// after logging in again. The original request is sent again, not the
+// login page it was redirected to, and each is retried at most once per
+// seed.
+func (cr *Crawler) registerRelogin(c *colly.Collector, s *seedCrawl) {
+	// origins maps colly's request IDs to the original requests
+	var origins, retried sync.Map
+	c.OnRequest(func(r *colly.Request) {
+		// Aborted requests never get a response to remove them
+		if s.ctx.Err() != nil || !cr.inScope(s, r.URL) {
+			return
+		}
+		u := *r.URL
+		origins.Store(r.ID, loginOrigin{url: &u, method: r.Method, body: requestBody(r), sent: time.Now()})
+	})
+	check := func(r *colly.Response) {
+		if r == nil || r.Request == nil || r.Request.URL == nil {
+			return
+		}
+		o, ok := origins.LoadAndDelete(r.Request.ID)
+		if !ok {
+			return
+		}
+		origin := o.(loginOrigin)
+		redirects := []string{r.Request.URL.String()}
+		if !cr.cfg.Login.Relogin.matches(r.StatusCode, redirects, r.Body) {
+			return
+		}
+		if _, done := retried.LoadOrStore(origin.method+" "+origin.url.String(), true); done {
+			return
+		}
+		loggedIn, err := cr.relogin(s.ctx, origin.sent)
+		if err != nil {
+			log.Println("[relogin]", err)
+			return
+		}
+		if !loggedIn {
+			return
+		}
+		retry := *r.Request
+		retry.URL, retry.Method = origin.url, origin.method
+		retry.Body = nil
+		if origin.body != nil {
+			retry.Body = bytes.NewReader(origin.body)
+		}
+		retry.Retry()
+	}
+	c.OnResponse(check)
+	c.OnError(func(r *colly.Response, _ error) {
+		check(r)
+	})
+}
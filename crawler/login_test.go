@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReloginRetriesOriginalRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// expired is the number of requests to /protected sent back to
+		// the login page
+		expired int64
+		behind  bool
+	}{
+		{"fresh session works", 1, true},
+		{"retried once only", 100, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testRelogin(t, tt.expired, tt.behind)
+		})
+	}
+}
+
+func testRelogin(t *testing.T, expired int64, behind bool) {
+	var logins, protected atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/protected">protected</a>`)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			n := logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: fmt.Sprint(n)})
+			fmt.Fprint(w, "welcome")
+			return
+		}
+		fmt.Fprint(w, `<form method="post"><input name="user"><input type="password" name="pass"></form>`)
+	})
+	mux.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		if protected.Add(1) <= expired {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, `<a href="/behind">behind</a>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Depth = 3
+	cfg.Login = &LoginSpec{
+		URL:     server.URL + "/login",
+		Fields:  map[string]string{"user": "alice", "pass": "s3cret"},
+		Relogin: LoginCondition{Redirect: "/login"},
+	}
+	cr, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cr.Login(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Past the debounce between logins
+	cr.login.last = time.Now().Add(-time.Minute)
+	results := run(t, cr, server.URL+"/")
+
+	if n := protected.Load(); n != 2 {
+		t.Errorf("/protected requested %d times, want 2", n)
+	}
+	if n := logins.Load(); n != 2 {
+		t.Errorf("%d logins, want 2", n)
+	}
+	if _, ok := findResult(results, "href", server.URL+"/behind"); ok != behind {
+		t.Errorf("page behind the login crawled: %v, want %v", ok, behind)
+	}
+}
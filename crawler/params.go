@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxParamExamples is the number of distinct example values kept per
+// parameter.
+const maxParamExamples = 5
+
+// Endpoint is the parameters seen for one scheme, host and path across the
+// whole crawl (-params).
+type Endpoint struct {
+	// URL is the endpoint without query or fragment.
+	URL string `json:"url"`
+	// Query maps the query parameter names seen on the endpoint to example
+	// values.
+	Query map[string][]string `json:"query,omitempty"`
+	// Form maps the input names of forms submitting to the endpoint to
+	// their default values.
+	Form map[string][]string `json:"form,omitempty"`
+	// Methods are the methods of those forms.
+	Methods []string `json:"methods,omitempty"`
+}
+
+// paramIndex aggregates Endpoints by endpoint URL.
+type paramIndex struct {
+	mu        sync.Mutex
+	endpoints map[string]*Endpoint
+}
+
+// endpointKey returns u without query and fragment, or "" for non-HTTP URLs.
+func endpointKey(u *url.URL) string {
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return ""
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host) + path
+}
+
+// endpoint returns the Endpoint for key, creating it. The caller holds mu.
+func (p *paramIndex) endpoint(key string) *Endpoint {
+	if p.endpoints == nil {
+		p.endpoints = make(map[string]*Endpoint)
+	}
+	ep, ok := p.endpoints[key]
+	if !ok {
+		ep = &Endpoint{URL: key}
+		p.endpoints[key] = ep
+	}
+	return ep
+}
+
+// addParam records name with an example value in params.
+func addParam(params map[string][]string, name, value string) {
+	examples := params[name]
+	if examples == nil {
+		examples = []string{}
+	}
+	if value != "" && len(examples) < maxParamExamples {
+		found := false
+		for _, v := range examples {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			examples = append(examples, value)
+		}
+	}
+	params[name] = examples
+}
+
+// addURL records the query parameters of rawURL.
+func (p *paramIndex) addURL(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return
+	}
+	key := endpointKey(u)
+	if key == "" {
+		return
+	}
+	query, _ := url.ParseQuery(u.RawQuery)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep := p.endpoint(key)
+	if ep.Query == nil {
+		ep.Query = make(map[string][]string)
+	}
+	for name, values := range query {
+		for _, v := range values {
+			addParam(ep.Query, name, v)
+		}
+	}
+}
+
+// addForm records the inputs of f.
+func (p *paramIndex) addForm(f *Form) {
+	u, err := url.Parse(f.Action)
+	if err != nil {
+		return
+	}
+	key := endpointKey(u)
+	if key == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep := p.endpoint(key)
+	if ep.Form == nil {
+		ep.Form = make(map[string][]string)
+	}
+	for _, field := range f.Fields {
+		addParam(ep.Form, field.Name, field.Value)
+	}
+	for _, m := range ep.Methods {
+		if m == f.Method {
+			return
+		}
+	}
+	ep.Methods = append(ep.Methods, f.Method)
+	sort.Strings(ep.Methods)
+}
+
+// list returns the endpoints sorted by URL.
+func (p *paramIndex) list() []Endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	endpoints := make([]Endpoint, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		endpoints = append(endpoints, *ep)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+	return endpoints
+}
+
+// Endpoints returns the parameters collected so far, by endpoint. It is
+// only populated when Config.Params is set.
+func (cr *Crawler) Endpoints() []Endpoint {
+	return cr.params.list()
+}
@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParamsCollectedWhateverIsReported(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>
+			<a href="/search?q=a&amp;page=2">1</a>
+			<a href="/search?q=b#results">2</a>
+			<a href="http://other.invalid/api?key=1">3</a>
+			<a href="/about">4</a>
+			<form action="/search"><input name="q"></form>
+			<form action="/login" method="post">
+				<input type="hidden" name="csrf" value="x">
+				<input name="user">
+			</form>
+		</body></html>`)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := []Endpoint{
+		{
+			URL:     server.URL + "/login",
+			Form:    map[string][]string{"csrf": {"x"}, "user": {}},
+			Methods: []string{"POST"},
+		},
+		{
+			URL:     server.URL + "/search",
+			Query:   map[string][]string{"q": {"a", "b"}, "page": {"2"}},
+			Form:    map[string][]string{"q": {}},
+			Methods: []string{"GET"},
+		},
+		{
+			URL:   "http://other.invalid/api",
+			Query: map[string][]string{"key": {"1"}},
+		},
+	}
+
+	printNothing, err := NewScope("nothing.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		report     ScopeReport
+		printScope *Scope
+	}{
+		{"report all", ReportAll, nil},
+		{"report out of scope", ReportOutOfScope, nil},
+		{"print scope", ReportAll, printNothing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Params = true
+			cfg.Report = tt.report
+			cfg.PrintScope = tt.printScope
+			cr, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run(t, cr, server.URL+"/")
+			if got := cr.Endpoints(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Endpoints() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
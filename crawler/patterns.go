@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a named regular expression matched against the text of every
+// element. Matches are reported with the pattern name as their source; if
+// the expression has a capturing group, the first group is reported.
+type Pattern struct {
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// patternNameRegex restricts pattern names so they read well as sources.
+var patternNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// LoadPatternFile reads named patterns, one "name: regex" per line.
+// Blank lines and lines starting with # are ignored.
+//
+//	# pattern file
+//	api-route: /api/v[0-9]+/[A-Za-z0-9_/\-]+
+//	s3-bucket: https?://[a-z0-9.\-]+\.s3\.amazonaws\.com/?
+func LoadPatternFile(filename string) ([]Pattern, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []Pattern
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !patternNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("%s:%d: pattern not formatted properly (expected name: regex)", filename, lineNo)
+		}
+		regex, err := regexp.Compile(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNo, err)
+		}
+		patterns = append(patterns, Pattern{Name: name, Regex: regex})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// match returns the matches of p in text, without duplicates.
+func (p Pattern) match(text string) []string {
+	var matches []string
+	seen := make(map[string]bool)
+	for _, m := range p.Regex.FindAllStringSubmatch(text, -1) {
+		match := m[0]
+		if len(m) > 1 {
+			match = m[1]
+		}
+		if match != "" && !seen[match] {
+			seen[match] = true
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import "time"
+
+// Result is a single URL discovered during a crawl.
+type Result struct {
+	// Source names the extractor that found the URL, e.g. href, script, form.
+	Source string `json:"source"`
+	// URL is the discovered URL, resolved against the page it was found on.
+	URL string `json:"url"`
+	// Where is the URL of the page the result was found on.
+	Where string `json:"where,omitempty"`
+	// Seed is the stdin URL whose crawl found the result.
+	Seed string `json:"seed"`
+	// Depth is the crawl depth of the page the result was found on; the
+	// seed itself is depth 1.
+	Depth int `json:"depth"`
+	// Status is the HTTP status code of the page the result was found on.
+	Status int `json:"status,omitempty"`
+	// ContentType is the Content-Type of the page the result was found on.
+	ContentType string `json:"content_type,omitempty"`
+	// Tag is the name of the HTML element the URL was found in.
+	Tag string `json:"tag,omitempty"`
+	// Attribute is the element attribute holding the URL, empty when the
+	// URL was extracted from text.
+	Attribute string `json:"attribute,omitempty"`
+	// SourceFile is the original source path, from a source map, that the
+	// URL was found in.
+	SourceFile string `json:"source_file,omitempty"`
+	// Confidence, between 0 and 1, is how likely a path extracted from
+	// JavaScript is a real endpoint. It is 0 for other results.
+	Confidence float64 `json:"confidence,omitempty"`
+	// Timestamp is when the result was discovered.
+	Timestamp time.Time `json:"timestamp"`
+	// URLStatus is the final HTTP status of the discovered URL itself, from
+	// the crawl's own fetch or a HEAD request with the crawl's headers and
+	// cookies. It is only set when Config.FetchStatus is enabled, and is 0
+	// if the URL could not be fetched or is out of scope.
+	URLStatus int `json:"url_status,omitempty"`
+	// Form is the structured record of a "form" result.
+	Form *Form `json:"form,omitempty"`
+	// InScope reports whether the URL is in the crawl scope, see
+	// Config.Scope. URLs out of scope are reported but never requested.
+	InScope bool `json:"in_scope"`
+}
@@ -0,0 +1,327 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// scopeLookupTimeout bounds the DNS lookup of a host for CIDR rules.
+	scopeLookupTimeout = 2 * time.Second
+	// maxScopeHosts bounds the number of hosts whose addresses are cached.
+	maxScopeHosts = 10000
+)
+
+// Scope decides which URLs are crawled (-scope). A URL is in scope if it
+// matches none of the exclude rules and, when there are include rules, at
+// least one of them. A nil Scope allows every URL.
+//
+// Rules are added before the scope is used; Allows is safe for concurrent
+// use.
+type Scope struct {
+	include []scopeRule
+	exclude []scopeRule
+	// addrs caches the addresses of hosts checked against CIDR rules, up
+	// to maxScopeHosts of them.
+	addrs  sync.Map
+	cached atomic.Int64
+}
+
+// scopeRule is a single rule of a Scope. Exactly one of host, cidr, regex
+// and prefix is set.
+type scopeRule struct {
+	// host is an exact host name, or a domain and its subdomains when
+	// wildcard is set. port, if set, must match as well.
+	host     string
+	wildcard bool
+	port     string
+	// cidr matches hosts that are, or resolve to, an address in the range.
+	cidr *net.IPNet
+	// regex is matched against the whole URL.
+	regex *regexp.Regexp
+	// prefix matches URLs starting with it.
+	prefix string
+}
+
+// ScopeReport selects the results reported by whether they are in the crawl
+// scope (-report).
+type ScopeReport string
+
+const (
+	// ReportAll reports every result, in scope or not.
+	ReportAll ScopeReport = "all"
+	// ReportInScope only reports results in scope.
+	ReportInScope ScopeReport = "in"
+	// ReportOutOfScope only reports results out of scope, such as third
+	// party links, which are never requested.
+	ReportOutOfScope ScopeReport = "out"
+)
+
+// ParseScopeReport validates a ScopeReport name.
+func ParseScopeReport(name string) (ScopeReport, error) {
+	switch r := ScopeReport(name); r {
+	case ReportAll, ReportInScope, ReportOutOfScope:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown report scope %q (want all, in or out)", name)
+}
+
+// includes reports whether res is reported.
+func (r ScopeReport) includes(res Result) bool {
+	switch r {
+	case ReportInScope:
+		return res.InScope
+	case ReportOutOfScope:
+		return !res.InScope
+	}
+	return true
+}
+
+// hostRuleRegex restricts host rules to host name characters.
+var hostRuleRegex = regexp.MustCompile(`^[a-z0-9_\-]+(\.[a-z0-9_\-]+)*\.?$`)
+
+// NewScope returns a Scope with the given rules, see Scope.Add.
+func NewScope(rules ...string) (*Scope, error) {
+	sc := &Scope{}
+	for _, rule := range rules {
+		if err := sc.Add(rule); err != nil {
+			return nil, err
+		}
+	}
+	return sc, nil
+}
+
+// DefaultScope returns the scope used when none is given: everything but
+// github.com.
+func DefaultScope() *Scope {
+	sc, _ := NewScope("-github.com")
+	return sc
+}
+
+// LoadScopeFile reads a Scope, one rule per line. Blank lines and lines
+// starting with # are ignored.
+//
+//	# scope file
+//	+*.target.com
+//	-admin.target.com
+//	+10.0.0.0/8
+//	-regex:\.(pdf|zip)$
+func LoadScopeFile(filename string) (*Scope, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sc := &Scope{}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := sc.Add(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Add adds a rule to the scope. A leading - makes it an exclude rule, a
+// leading + or nothing an include rule. The rest is one of:
+//
+//	target.com           the host target.com
+//	*.target.com         target.com and its subdomains
+//	target.com:8443      the host on that port only
+//	10.0.0.0/8, 1.2.3.4  hosts that are or resolve to an address in the range
+//	regex:<expression>   URLs matching the regular expression
+//	https://target.com/a URLs starting with the given prefix
+func (sc *Scope) Add(rule string) error {
+	include := true
+	switch {
+	case strings.HasPrefix(rule, "-"):
+		include = false
+		rule = rule[1:]
+	case strings.HasPrefix(rule, "+"):
+		rule = rule[1:]
+	}
+	rule = strings.TrimSpace(rule)
+	r, err := parseScopeRule(rule)
+	if err != nil {
+		return err
+	}
+	if include {
+		sc.include = append(sc.include, r)
+	} else {
+		sc.exclude = append(sc.exclude, r)
+	}
+	return nil
+}
+
+func parseScopeRule(rule string) (scopeRule, error) {
+	if rule == "" {
+		return scopeRule{}, errors.New("empty scope rule")
+	}
+	if expr, ok := strings.CutPrefix(rule, "regex:"); ok {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return scopeRule{}, err
+		}
+		return scopeRule{regex: regex}, nil
+	}
+	if strings.Contains(rule, "://") {
+		if _, err := url.Parse(rule); err != nil {
+			return scopeRule{}, err
+		}
+		return scopeRule{prefix: rule}, nil
+	}
+	if strings.Contains(rule, "/") {
+		_, cidr, err := net.ParseCIDR(rule)
+		if err != nil {
+			return scopeRule{}, err
+		}
+		return scopeRule{cidr: cidr}, nil
+	}
+	if ip := net.ParseIP(strings.Trim(rule, "[]")); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return scopeRule{cidr: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}}, nil
+	}
+
+	r := scopeRule{host: strings.ToLower(rule)}
+	if host, port, err := net.SplitHostPort(r.host); err == nil {
+		if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+			return scopeRule{}, fmt.Errorf("invalid port in scope rule %q", rule)
+		}
+		r.host, r.port = host, port
+	}
+	if host, ok := strings.CutPrefix(r.host, "*."); ok {
+		r.host, r.wildcard = host, true
+	}
+	if !hostRuleRegex.MatchString(r.host) {
+		return scopeRule{}, fmt.Errorf("invalid scope rule %q", rule)
+	}
+	r.host = strings.TrimSuffix(r.host, ".")
+	return r, nil
+}
+
+// Allows reports whether u is in scope.
+func (sc *Scope) Allows(u *url.URL) bool {
+	return sc.allows(context.Background(), u)
+}
+
+// allows is Allows with the DNS lookups of CIDR rules bound to ctx. CIDR
+// rules are only checked once the other rules leave the answer open, so
+// hosts are not resolved when a host or regex rule decides.
+func (sc *Scope) allows(ctx context.Context, u *url.URL) bool {
+	if sc == nil {
+		return true
+	}
+	if sc.matchAny(ctx, sc.exclude, u, false) {
+		return false
+	}
+	if len(sc.include) != 0 && !sc.matchAny(ctx, sc.include, u, false) && !sc.matchAny(ctx, sc.include, u, true) {
+		return false
+	}
+	return !sc.matchAny(ctx, sc.exclude, u, true)
+}
+
+// matchAny reports whether u matches one of rules, among the CIDR rules if
+// cidr is set and among the others otherwise.
+func (sc *Scope) matchAny(ctx context.Context, rules []scopeRule, u *url.URL, cidr bool) bool {
+	for _, r := range rules {
+		if (r.cidr != nil) == cidr && sc.match(ctx, r, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsURL is Allows for a URL string. URLs that can't be parsed are out
+// of scope.
+func (sc *Scope) AllowsURL(rawURL string) bool {
+	if sc == nil {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return sc.Allows(u)
+}
+
+func (sc *Scope) match(ctx context.Context, r scopeRule, u *url.URL) bool {
+	switch {
+	case r.regex != nil:
+		return r.regex.MatchString(u.String())
+	case r.prefix != "":
+		return strings.HasPrefix(u.String(), r.prefix)
+	case r.cidr != nil:
+		for _, ip := range sc.lookup(ctx, u.Hostname()) {
+			if r.cidr.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if r.port != "" && r.port != urlPort(u) {
+		return false
+	}
+	return host == r.host || r.wildcard && strings.HasSuffix(host, "."+r.host)
+}
+
+// lookup returns the addresses of host, resolving and caching names. It
+// returns nil if host can't be resolved within scopeLookupTimeout.
+func (sc *Scope) lookup(ctx context.Context, host string) []net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+	if ips, ok := sc.addrs.Load(host); ok {
+		return ips.([]net.IP)
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, scopeLookupTimeout)
+	defer cancel()
+	addrs, _ := net.DefaultResolver.LookupIPAddr(lookupCtx, host)
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	// Lookups cut short by the caller are not cached
+	if ctx.Err() == nil && sc.cached.Load() < maxScopeHosts {
+		if _, loaded := sc.addrs.LoadOrStore(host, ips); !loaded {
+			sc.cached.Add(1)
+		}
+	}
+	return ips
+}
+
+// urlPort returns the port of u, or the default port of its scheme.
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		in    []string
+		out   []string
+	}{
+		{
+			"no rules", nil,
+			[]string{"https://any.com/", "http://10.0.0.1/"}, nil,
+		},
+		{
+			"host", []string{"target.com"},
+			[]string{"https://target.com/", "http://TARGET.com./a", "https://target.com:8443/"},
+			[]string{"https://www.target.com/", "https://nottarget.com/", "https://target.com.evil.com/"},
+		},
+		{
+			"host and port", []string{"target.com:8443"},
+			[]string{"https://target.com:8443/"},
+			[]string{"https://target.com/", "http://target.com:80/"},
+		},
+		{
+			"default port", []string{"target.com:443"},
+			[]string{"https://target.com/", "wss://target.com/ws"},
+			[]string{"http://target.com/", "https://target.com:8443/"},
+		},
+		{
+			"wildcard", []string{"*.target.com"},
+			[]string{"https://target.com/", "https://a.b.target.com/"},
+			[]string{"https://eviltarget.com/", "https://target.com.evil.com/"},
+		},
+		{
+			"exclude", []string{"-github.com"},
+			[]string{"https://target.com/", "https://api.github.com/"},
+			[]string{"https://github.com/x"},
+		},
+		{
+			"exclude wins", []string{"+*.target.com", "-admin.target.com"},
+			[]string{"https://www.target.com/"},
+			[]string{"https://admin.target.com/", "https://other.com/"},
+		},
+		{
+			"cidr", []string{"10.0.0.0/8"},
+			[]string{"http://10.1.2.3/", "http://10.0.0.1:8080/"},
+			[]string{"http://11.0.0.1/", "http://[::1]/"},
+		},
+		{
+			"ip", []string{"192.168.1.1"},
+			[]string{"http://192.168.1.1/"},
+			[]string{"http://192.168.1.2/"},
+		},
+		{
+			"ipv6 cidr", []string{"fd00::/8"},
+			[]string{"http://[fd00::1]/"},
+			[]string{"http://[fe80::1]/", "http://10.0.0.1/"},
+		},
+		{
+			"regex", []string{"-regex:\\.(pdf|zip)$"},
+			[]string{"https://t.com/a.html", "https://t.com/pdf"},
+			[]string{"https://t.com/a.pdf", "https://t.com/b.zip"},
+		},
+		{
+			"prefix", []string{"https://target.com/app/"},
+			[]string{"https://target.com/app/", "https://target.com/app/x?y=1"},
+			[]string{"https://target.com/", "http://target.com/app/", "https://target.com.evil.com/app/"},
+		},
+		{
+			"any include", []string{"a.com", "b.com"},
+			[]string{"https://a.com/", "https://b.com/"},
+			[]string{"https://c.com/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := NewScope(tt.rules...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, u := range tt.in {
+				if !sc.AllowsURL(u) {
+					t.Errorf("%q is out of scope, want in", u)
+				}
+			}
+			for _, u := range tt.out {
+				if sc.AllowsURL(u) {
+					t.Errorf("%q is in scope, want out", u)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeCIDRResolvesHosts(t *testing.T) {
+	sc, err := NewScope("10.0.0.0/8", "-regex:/logout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.addrs.Store("internal.target.com", []net.IP{net.ParseIP("10.0.0.5")})
+	sc.addrs.Store("www.target.com", []net.IP{net.ParseIP("203.0.113.5")})
+	if !sc.AllowsURL("https://internal.target.com/") {
+		t.Error("host resolving into the range is out of scope")
+	}
+	if sc.AllowsURL("https://www.target.com/") {
+		t.Error("host resolving outside the range is in scope")
+	}
+
+	// Excluded URLs are decided without a lookup
+	if sc.AllowsURL("https://unknown.invalid/logout") {
+		t.Error("excluded URL is in scope")
+	}
+	if _, ok := sc.addrs.Load("unknown.invalid"); ok {
+		t.Error("host of an excluded URL was resolved")
+	}
+}
+
+func TestNilScope(t *testing.T) {
+	var sc *Scope
+	u, _ := url.Parse("https://any.com/")
+	if !sc.Allows(u) || !sc.AllowsURL("https://any.com/") {
+		t.Error("nil scope doesn't allow every URL")
+	}
+}
+
+func TestDefaultScope(t *testing.T) {
+	sc := DefaultScope()
+	if sc.AllowsURL("https://github.com/x") || !sc.AllowsURL("https://target.com/") {
+		t.Error("default scope must only exclude github.com")
+	}
+}
+
+func TestScopeMalformedRules(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"-",
+		"+ ",
+		"regex:(",
+		"10.0.0.0/33",
+		"10.0.0/8",
+		"bad host",
+		"target.com/path",
+		"*.",
+		"a..b",
+		"http://[::1",
+		"target.com:port",
+		"target.com:70000",
+		"target.com:",
+	} {
+		if _, err := NewScope(rule); err == nil {
+			t.Errorf("NewScope(%q) accepted a malformed rule", rule)
+		}
+	}
+}
+
+func TestLoadScopeFile(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "scope.txt")
+	if err := os.WriteFile(good, []byte("# scope\n\n+*.target.com\n-admin.target.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScopeFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.AllowsURL("https://www.target.com/") || sc.AllowsURL("https://admin.target.com/") {
+		t.Error("scope file rules not applied")
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("target.com\n\nregex:(\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScopeFile(bad); err == nil || !strings.Contains(err.Error(), "bad.txt:3:") {
+		t.Errorf("LoadScopeFile error = %v, want one naming line 3", err)
+	}
+}
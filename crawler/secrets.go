@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// snippetContext is the number of bytes kept on each side of a secret in
+// Finding.Snippet.
+const snippetContext = 40
+
+// SecretRule detects one kind of secret in response bodies. If Regex has a
+// capturing group, the first group is the secret, otherwise the whole
+// match is.
+type SecretRule struct {
+	ID    string
+	Regex *regexp.Regexp
+	// MinEntropy, when non-zero, is the minimum Shannon entropy of the
+	// secret in bits per character, to weed out placeholders such as
+	// "xxxxxxxx" or "your_api_key_here".
+	MinEntropy float64
+}
+
+// Finding is a secret found in a crawled response.
+type Finding struct {
+	// Rule is the ID of the rule that matched.
+	Rule string `json:"rule"`
+	// Match is the secret itself.
+	Match string `json:"match"`
+	// Snippet is the match with some surrounding text, on one line.
+	Snippet   string    `json:"snippet"`
+	URL       string    `json:"url"`
+	Seed      string    `json:"seed"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DefaultSecretRules returns the built-in secret rules (-secrets).
+func DefaultSecretRules() []SecretRule {
+	return []SecretRule{
+		{ID: "aws-access-key-id", Regex: regexp.MustCompile(`\b((?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16})\b`)},
+		{ID: "aws-secret-access-key", Regex: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?['"]([A-Za-z0-9/+=]{40})['"]`), MinEntropy: 4},
+		{ID: "gcp-api-key", Regex: regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})`)},
+		{ID: "gcp-service-account", Regex: regexp.MustCompile(`"type"\s*:\s*"service_account"`)},
+		{ID: "stripe-secret-key", Regex: regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,99})\b`)},
+		{ID: "slack-token", Regex: regexp.MustCompile(`\b(xox[abprs]-[0-9A-Za-z\-]{10,72})`)},
+		{ID: "slack-webhook", Regex: regexp.MustCompile(`https://hooks\.slack\.com/services/T[A-Za-z0-9_]+/B[A-Za-z0-9_]+/[A-Za-z0-9_]+`)},
+		{ID: "github-token", Regex: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255})\b`)},
+		{ID: "jwt", Regex: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,})`)},
+		{ID: "private-key", Regex: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+		{ID: "generic-secret", Regex: regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d)["']?\s*[:=]\s*["']([A-Za-z0-9_\-+/=.]{16,})["']`), MinEntropy: 3.5},
+		{ID: "internal-ip", Regex: regexp.MustCompile(`\b((?:10\.(?:25[0-5]|2[0-4]\d|1?\d?\d)|172\.(?:1[6-9]|2\d|3[01])|192\.168)\.(?:25[0-5]|2[0-4]\d|1?\d?\d)\.(?:25[0-5]|2[0-4]\d|1?\d?\d))\b`)},
+		{ID: "internal-host", Regex: regexp.MustCompile(`(?i)\b((?:[a-z0-9](?:[a-z0-9\-]*[a-z0-9])?\.)+(?:internal|intranet|corp|lan|localdomain))\b`)},
+	}
+}
+
+// LoadSecretRules reads extra secret rules from a YAML list, e.g.
+//
+//   - id: acme-token
+//     regex: 'acme_[A-Za-z0-9]{32}'
+//     entropy: 3.5
+func LoadSecretRules(filename string) ([]SecretRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		ID      string  `yaml:"id"`
+		Regex   string  `yaml:"regex"`
+		Entropy float64 `yaml:"entropy"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	rules := make([]SecretRule, 0, len(raw))
+	for i, r := range raw {
+		if r.ID == "" || r.Regex == "" {
+			return nil, fmt.Errorf("%s: rule %d needs an id and a regex", filename, i+1)
+		}
+		regex, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %v", filename, r.ID, err)
+		}
+		rules = append(rules, SecretRule{ID: r.ID, Regex: regex, MinEntropy: r.Entropy})
+	}
+	return rules, nil
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// findSecrets returns the matches of rules in body. A secret matched twice
+// by the same rule is only returned once.
+func findSecrets(rules []SecretRule, body string) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		for _, loc := range rule.Regex.FindAllStringSubmatchIndex(body, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) > 3 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			match := body[start:end]
+			if rule.MinEntropy > 0 && shannonEntropy(match) < rule.MinEntropy {
+				continue
+			}
+			if key := rule.ID + "\x00" + match; !seen[key] {
+				seen[key] = true
+				findings = append(findings, Finding{Rule: rule.ID, Match: match, Snippet: snippet(body, start, end)})
+			}
+		}
+	}
+	return findings
+}
+
+// snippet returns body[start:end] with up to snippetContext bytes of
+// context on each side, whitespace collapsed to single spaces.
+func snippet(body string, start, end int) string {
+	from, to := start-snippetContext, end+snippetContext
+	if from < 0 {
+		from = 0
+	}
+	if to > len(body) {
+		to = len(body)
+	}
+	return strings.ToValidUTF8(strings.Join(strings.Fields(body[from:to]), " "), "")
+}
+
+// isTextResponse reports whether r is worth scanning for secrets: HTML,
+// scripts, JSON, XML and other text.
+func isTextResponse(r *colly.Response) bool {
+	contentType := strings.ToLower(r.Headers.Get("Content-Type"))
+	if contentType == "" {
+		return true
+	}
+	for _, t := range []string{"text/", "javascript", "ecmascript", "json", "xml"} {
+		if strings.Contains(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerSecretScanner scans every text response, inline scripts
+// included, with Config.SecretRules and passes findings to
+// Config.OnFinding. A secret is reported once per seed and rule.
+func (cr *Crawler) registerSecretScanner(c *colly.Collector, s *seedCrawl) {
+	c.OnResponse(func(r *colly.Response) {
+		if !isTextResponse(r) {
+			return
+		}
+		for _, f := range findSecrets(cr.cfg.SecretRules, string(r.Body)) {
+			if _, dup := s.secrets.LoadOrStore(f.Rule+"\x00"+f.Match, true); dup {
+				continue
+			}
+			f.URL = r.Request.URL.String()
+			f.Seed = s.seed
+			f.Timestamp = time.Now().UTC()
+			cr.stats.findings.Add(1)
+			if cr.cfg.OnFinding != nil {
+				cr.cfg.OnFinding(f)
+			}
+		}
+	})
+}
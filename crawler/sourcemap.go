@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// sourceMappingRegex matches the //# sourceMappingURL= comment of a bundle.
+var sourceMappingRegex = regexp.MustCompile(`(?m)[/*]\s*[#@]\s*sourceMappingURL\s*=\s*([^\s*'"]+)`)
+
+// sourceMap is the subset of a source map v3 document paxkk reads.
+type sourceMap struct {
+	Version        int       `json:"version"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+}
+
+// sourceMapURL returns the source map reference of a JavaScript response,
+// from the SourceMap header or the last sourceMappingURL comment.
+func sourceMapURL(r *colly.Response) string {
+	for _, header := range []string{"SourceMap", "X-SourceMap"} {
+		if v := r.Headers.Get(header); v != "" {
+			return v
+		}
+	}
+	matches := sourceMappingRegex.FindAllSubmatch(r.Body, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// parseSourceMap decodes a source map, returning nil if data isn't one.
+func parseSourceMap(data []byte) *sourceMap {
+	// Maps may start with the )]}' XSSI guard
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, ")]}") {
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	var sm sourceMap
+	if err := json.Unmarshal([]byte(text), &sm); err != nil || sm.Version == 0 || len(sm.Sources) == 0 {
+		return nil
+	}
+	return &sm
+}
+
+// decodeDataMap decodes an inline data: URI source map.
+func decodeDataMap(ref string) []byte {
+	comma := strings.IndexByte(ref, ',')
+	if comma < 0 {
+		return nil
+	}
+	meta, payload := ref[len("data:"):comma], ref[comma+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil
+	}
+	return []byte(data)
+}
+
+// registerSourceMapExtractors follows the source maps referenced by
+// JavaScript files. A map that can be fetched is reported with source
+// "sourcemap" and the bundle as Where, then the endpoint extractors are run
+// over every original source. Those results have source "sourcemap-source"
+// and Result.SourceFile set to the original path.
+func (cr *Crawler) registerSourceMapExtractors(c *colly.Collector, s *seedCrawl) {
+	// bundles maps source map URLs to the bundle that referenced them
+	bundles := make(map[string]string)
+	var mu sync.Mutex
+
+	c.OnResponse(func(r *colly.Response) {
+		if isJSResponse(r) {
+			ref := sourceMapURL(r)
+			if ref == "" {
+				return
+			}
+			if strings.HasPrefix(ref, "data:") {
+				if sm := parseSourceMap(decodeDataMap(ref)); sm != nil {
+					cr.extractSourceMap(s, r, sm)
+				}
+				return
+			}
+			mapURL := r.Request.AbsoluteURL(ref)
+			if mapURL == "" {
+				return
+			}
+			mu.Lock()
+			bundles[mapURL] = r.Request.URL.String()
+			mu.Unlock()
+			c.Request("GET", mapURL, nil, scriptContext(r.Ctx.Get(scriptPageKey), resultDepth(r.Request)), nil)
+			return
+		}
+
+		mu.Lock()
+		bundle, ok := bundles[r.Request.URL.String()]
+		mu.Unlock()
+		if !ok {
+			return
+		}
+		sm := parseSourceMap(r.Body)
+		if sm == nil {
+			return
+		}
+		res := Result{
+			Source:      "sourcemap",
+			URL:         r.Request.URL.String(),
+			Where:       bundle,
+			Seed:        s.seed,
+			Depth:       resultDepth(r.Request),
+			Status:      r.StatusCode,
+			ContentType: r.Headers.Get("Content-Type"),
+		}
+		if len(cr.cfg.Keywords) == 0 || containsKeyword(res.URL, cr.cfg.Keywords) {
+			cr.send(s, res)
+		}
+		cr.extractSourceMap(s, r, sm)
+	})
+}
+
+// extractSourceMap reports the endpoints of every original source embedded
+// in sm, which was served by r. Relative endpoints are resolved against the
+// page that loaded the bundle.
+func (cr *Crawler) extractSourceMap(s *seedCrawl, r *colly.Response, sm *sourceMap) {
+	for i, content := range sm.SourcesContent {
+		if content == nil || i >= len(sm.Sources) {
+			continue
+		}
+		for _, ep := range extractJSEndpoints(*content, cr.cfg.JSRelative) {
+			cr.emitFrom(s, r.Request, r, resolveJSEndpoint(r, ep.URL), Result{
+				Source:     "sourcemap-source",
+				SourceFile: sm.Sources[i],
+				Confidence: ep.Confidence,
+			})
+		}
+	}
+}
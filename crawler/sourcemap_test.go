@@ -0,0 +1,27 @@
+package crawler
+
+import "testing"
+
+func TestSourceMapEndpointsResolveAgainstPage(t *testing.T) {
+	site, cdn := newScriptServers(t, map[string]string{
+		"/js/app.js":     "console.log(1)\n//# sourceMappingURL=app.js.map\n",
+		"/js/app.js.map": `{"version":3,"sources":["src/a.js"],"sourcesContent":["fetch(\"/api/secret\"); fetch(\"v2/from-map\")"]}`,
+	})
+	cfg := testConfig()
+	cfg.JSRelative = true
+	results := crawl(t, cfg, site.URL+"/app/")
+
+	if _, ok := findResult(results, "sourcemap", cdn.URL+"/js/app.js.map"); !ok {
+		t.Fatalf("source map not reported: %+v", results)
+	}
+	for _, want := range []string{site.URL + "/api/secret", site.URL + "/app/v2/from-map"} {
+		res, ok := findResult(results, "sourcemap-source", want)
+		if !ok {
+			t.Errorf("sourcemap-source %s not reported", want)
+			continue
+		}
+		if res.SourceFile != "src/a.js" {
+			t.Errorf("SourceFile = %q, want src/a.js", res.SourceFile)
+		}
+	}
+}
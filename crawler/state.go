@@ -0,0 +1,327 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+)
+
+const (
+	stateSeedsFile    = "seeds.done"
+	stateFrontierFile = "frontier.log"
+)
+
+// State persists the progress of a crawl in a directory so an interrupted
+// run can be resumed (-state, -resume). It records the seeds crawled to
+// the end and the frontier: requests scheduled but not finished. Visited
+// and -u sets are kept next to it by the caller, see Config.Visited.
+//
+// The frontier is an append-only log of JSON lines, compacted when the
+// state is opened.
+type State struct {
+	dir string
+
+	mu       sync.Mutex
+	done     map[string]bool
+	pending  map[string]map[string]json.RawMessage
+	seeds    *os.File
+	frontier *os.File
+}
+
+// frontierEntry is a line of the frontier log. Op is "+" when a request is
+// scheduled, with Request set, and "-" when it is finished.
+type frontierEntry struct {
+	Op      string          `json:"op"`
+	Seed    string          `json:"seed"`
+	ID      string          `json:"id"`
+	Request json.RawMessage `json:"request,omitempty"`
+}
+
+// frontierRequest has the fields of colly's serialized requests, so
+// entries can be read back with Collector.UnmarshalRequest. Ctx keeps the
+// request's context values, such as the page that loaded a script.
+type frontierRequest struct {
+	URL     string
+	Method  string
+	Depth   int
+	Body    []byte
+	Ctx     map[string]interface{}
+	Headers http.Header
+}
+
+// frontierIDKey is the colly context key holding the frontier ID of a
+// resumed request, so finishing it clears the entry of the previous run.
+const frontierIDKey = "frontier-id"
+
+// OpenState opens the state directory dir, creating it if needed. Unless
+// resume is set, dir must not hold the state of another crawl.
+func OpenState(dir string, resume bool) (*State, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	st := &State{
+		dir:     dir,
+		done:    make(map[string]bool),
+		pending: make(map[string]map[string]json.RawMessage),
+	}
+	if !resume {
+		for _, name := range []string{stateSeedsFile, stateFrontierFile} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Size() > 0 {
+				return nil, fmt.Errorf("%s already holds a crawl; resume it or use another directory", dir)
+			}
+		}
+	} else {
+		if err := st.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	st.seeds, err = os.OpenFile(filepath.Join(dir, stateSeedsFile), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	st.frontier, err = os.OpenFile(filepath.Join(dir, stateFrontierFile), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		st.seeds.Close()
+		return nil, err
+	}
+	return st, nil
+}
+
+// load reads the completed seeds, replays the frontier log and rewrites it
+// with only the pending entries.
+func (st *State) load() error {
+	if f, err := os.Open(filepath.Join(st.dir, stateSeedsFile)); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if seed := scanner.Text(); seed != "" {
+				st.done[seed] = true
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	path := filepath.Join(st.dir, stateFrontierFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		var entry frontierEntry
+		// A torn last line from a crash is skipped
+		if len(bytes.TrimSpace(line)) != 0 && json.Unmarshal(line, &entry) == nil {
+			switch entry.Op {
+			case "+":
+				if st.pending[entry.Seed] == nil {
+					st.pending[entry.Seed] = make(map[string]json.RawMessage)
+				}
+				st.pending[entry.Seed][entry.ID] = entry.Request
+			case "-":
+				delete(st.pending[entry.Seed], entry.ID)
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	f.Close()
+
+	tmp, err := os.CreateTemp(st.dir, stateFrontierFile+".*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for seed, requests := range st.pending {
+		if st.done[seed] {
+			delete(st.pending, seed)
+			continue
+		}
+		for id, req := range requests {
+			enc.Encode(frontierEntry{Op: "+", Seed: seed, ID: id, Request: req})
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Close closes the state files.
+func (st *State) Close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	err := st.frontier.Close()
+	if err2 := st.seeds.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// completed reports whether seed was crawled to the end by a previous run.
+func (st *State) completed(seed string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.done[seed]
+}
+
+// markCompleted records that seed was crawled to the end.
+func (st *State) markCompleted(seed string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.done[seed] = true
+	delete(st.pending, seed)
+	if _, err := st.seeds.WriteString(seed + "\n"); err != nil {
+		log.Println("[state]", err)
+	}
+}
+
+// takePending returns the unfinished requests of seed left by a previous
+// run by frontier ID, and forgets them.
+func (st *State) takePending(seed string) map[string]json.RawMessage {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	requests := st.pending[seed]
+	delete(st.pending, seed)
+	return requests
+}
+
+// write appends an entry to the frontier log.
+func (st *State) write(entry frontierEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, err := st.frontier.Write(append(line, '\n')); err != nil {
+		log.Println("[state]", err)
+	}
+}
+
+// requestHash is colly's visited set key of a request: the URL, plus the
+// body for methods other than GET.
+func requestHash(u, method string, body []byte) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(u))
+	if method != "GET" {
+		h.Write(body)
+	}
+	return h.Sum64()
+}
+
+// requestBody returns the body of r without consuming it.
+func requestBody(r *colly.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	seeker, ok := r.Body.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	body, _ := io.ReadAll(r.Body)
+	seeker.Seek(0, io.SeekStart)
+	return body
+}
+
+// registerFrontier logs the requests of s to Config.State as they are
+// scheduled and finished. A request is finished once its page has been
+// scraped, so the links it queued are already logged, or once it failed.
+// Requests stopped by cancellation stay pending.
+func (cr *Crawler) registerFrontier(c *colly.Collector, s *seedCrawl) {
+	// ids maps colly's request IDs to frontier IDs while in flight
+	var ids sync.Map
+	c.OnRequest(func(r *colly.Request) {
+		// Requests out of scope are aborted before being sent
+		if !cr.inScope(s, r.URL) {
+			return
+		}
+		body := requestBody(r)
+		// A resumed request keeps its ID. The context is shared with the
+		// requests it leads to, so they don't inherit it.
+		id := r.Ctx.Get(frontierIDKey)
+		if id != "" {
+			r.Ctx.Put(frontierIDKey, "")
+		} else {
+			id = strconv.FormatUint(requestHash(r.URL.String(), r.Method, body), 16)
+		}
+		ctx := make(map[string]interface{})
+		r.Ctx.ForEach(func(k string, v interface{}) interface{} {
+			if k != frontierIDKey {
+				ctx[k] = v
+			}
+			return nil
+		})
+		req, err := json.Marshal(frontierRequest{URL: r.URL.String(), Method: r.Method, Depth: r.Depth, Body: body, Ctx: ctx, Headers: *r.Headers})
+		if err != nil {
+			return
+		}
+		ids.Store(r.ID, id)
+		cr.cfg.State.write(frontierEntry{Op: "+", Seed: s.seed, ID: id, Request: req})
+	})
+	finish := func(r *colly.Request) {
+		if id, ok := ids.LoadAndDelete(r.ID); ok {
+			cr.cfg.State.write(frontierEntry{Op: "-", Seed: s.seed, ID: id.(string)})
+		}
+	}
+	c.OnScraped(func(r *colly.Response) {
+		finish(r.Request)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if s.ctx.Err() == nil && r != nil && r.Request != nil {
+			finish(r.Request)
+		}
+	})
+}
+
+// resumeFrontier re-issues the unfinished requests of a previous run, by
+// frontier ID. They may already be in the persisted visited set, so each is
+// let through once.
+func (cr *Crawler) resumeFrontier(s *seedCrawl, c *colly.Collector, pending map[string]json.RawMessage) {
+	for id, data := range pending {
+		req, err := c.UnmarshalRequest(data)
+		if err != nil {
+			continue
+		}
+		req.Ctx.Put(frontierIDKey, id)
+		if s.visited != nil {
+			// Request.Do checks the visited set with the URL re-encoded,
+			// which isn't always the string hashed by the previous run
+			var fr frontierRequest
+			json.Unmarshal(data, &fr)
+			s.visited.allowRevisit(requestHash(req.URL.String(), req.Method, fr.Body))
+		}
+		req.Do()
+	}
+}
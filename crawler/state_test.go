@@ -0,0 +1,145 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xerocorps/paxkk/dedup"
+)
+
+// blockOnce returns a handler that blocks the first GET request matching
+// block until the client gives up, signalling entered, and serves the
+// others with next.
+func blockOnce(block func(*http.Request) bool, entered chan<- struct{}, next http.Handler) http.Handler {
+	var blocked atomic.Bool
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && block(r) && blocked.CompareAndSwap(false, true) {
+			close(entered)
+			<-r.Context().Done()
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// interruptAndResume crawls seed until entered is closed, interrupts the
+// crawl, then resumes it from the saved state in dir. It returns the
+// results of the resumed run.
+func interruptAndResume(t *testing.T, cfg Config, dir, seed string, entered <-chan struct{}) []Result {
+	t.Helper()
+	cfg.Visited = dedup.NewMemory()
+
+	state, err := OpenState(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.State = state
+	cr, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	seeds := make(chan string, 1)
+	seeds <- seed
+	close(seeds)
+	results := cr.Run(ctx, seeds)
+	select {
+	case <-entered:
+	case <-time.After(10 * time.Second):
+		t.Fatal("blocked request never sent")
+	}
+	cancel()
+	for range results {
+	}
+	if err := state.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.State, err = OpenState(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.State.Close()
+	return crawl(t, cfg, seed)
+}
+
+// pendingIDs replays the frontier log of dir and returns the IDs still
+// pending.
+func pendingIDs(t *testing.T, dir string) []string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, stateFrontierFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pending := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry frontierEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		pending[entry.ID] = entry.Op == "+"
+	}
+	var ids []string
+	for id, p := range pending {
+		if p {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestResumeNormalizedURL(t *testing.T) {
+	entered := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/next">next</a>`)
+	})
+	server := httptest.NewServer(blockOnce(func(r *http.Request) bool { return r.URL.Path == "/start page" }, entered, mux))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.Depth = 1
+	// String() escapes the space and keeps the empty query
+	seed := server.URL + "/start page?"
+	results := interruptAndResume(t, cfg, dir, seed, entered)
+
+	if _, ok := findResult(results, "href", server.URL+"/next"); !ok {
+		t.Errorf("resumed request not crawled: %+v", results)
+	}
+	if ids := pendingIDs(t, dir); len(ids) != 0 {
+		t.Errorf("frontier entries still pending after the resumed run: %v", ids)
+	}
+}
+
+func TestResumeKeepsScriptPage(t *testing.T) {
+	entered := make(chan struct{})
+	cdn := httptest.NewServer(blockOnce(func(r *http.Request) bool { return true }, entered, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `fetch("/api/users")`)
+	})))
+	defer cdn.Close()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<script src="%s/app.js"></script>`, cdn.URL)
+	}))
+	defer site.Close()
+
+	dir := t.TempDir()
+	results := interruptAndResume(t, testConfig(), dir, site.URL+"/", entered)
+
+	if _, ok := findResult(results, "js-file", site.URL+"/api/users"); !ok {
+		t.Errorf("resumed script lost its page: %+v", results)
+	}
+	if ids := pendingIDs(t, dir); len(ids) != 0 {
+		t.Errorf("frontier entries still pending after the resumed run: %v", ids)
+	}
+}
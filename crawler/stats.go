@@ -0,0 +1,49 @@
+package crawler
+
+import "sync/atomic"
+
+// Stats summarises a crawl. It is safe to call Crawler.Stats while Run is
+// in progress.
+type Stats struct {
+	// Seeds is the number of seed URLs received.
+	Seeds int64
+	// Completed is the number of seeds crawled to the end.
+	Completed int64
+	// TimedOut is the number of seeds stopped by Config.Timeout.
+	TimedOut int64
+	// Cancelled is the number of seeds stopped because the crawl was cancelled.
+	Cancelled int64
+	// Skipped is the number of seeds that were invalid or not reachable.
+	Skipped int64
+	// Denied is the number of seeds skipped because their host resolves to
+	// an address denied by Config.IPPolicy, see Crawler.DeniedHosts.
+	Denied int64
+	// Previous is the number of seeds skipped because Config.State records
+	// them as completed by an earlier run.
+	Previous int64
+	// Requests is the number of requests sent by the collectors.
+	Requests int64
+	// Results is the number of results sent on the Run channel.
+	Results int64
+	// Findings is the number of secrets found by Config.SecretRules.
+	Findings int64
+}
+
+type stats struct {
+	seeds, completed, timedOut, cancelled, skipped, denied, previous, requests, results, findings atomic.Int64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Seeds:     s.seeds.Load(),
+		Completed: s.completed.Load(),
+		TimedOut:  s.timedOut.Load(),
+		Cancelled: s.cancelled.Load(),
+		Skipped:   s.skipped.Load(),
+		Denied:    s.denied.Load(),
+		Previous:  s.previous.Load(),
+		Requests:  s.requests.Load(),
+		Results:   s.results.Load(),
+		Findings:  s.findings.Load(),
+	}
+}
@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFieldValues are the dummy values submitted for each input type
+// when neither Config.FormValues nor the form itself provides one.
+var defaultFieldValues = map[string]string{
+	"text":           "test",
+	"search":         "test",
+	"textarea":       "test",
+	"email":          "test@example.com",
+	"password":       "Password123!",
+	"number":         "1",
+	"range":          "1",
+	"tel":            "5555555555",
+	"url":            "https://example.com/",
+	"date":           "2024-01-01",
+	"datetime-local": "2024-01-01T12:00",
+	"month":          "2024-01",
+	"week":           "2024-W01",
+	"time":           "12:00",
+	"color":          "#000000",
+	"checkbox":       "on",
+	"radio":          "on",
+}
+
+// LoadFormValues reads the values to submit by field name from a YAML
+// mapping, e.g.
+//
+//	email: pentest@example.com
+//	q: admin
+func LoadFormValues(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return values, nil
+}
+
+// fieldValue returns the value submitted for field, and false if the field
+// is left out: file inputs and buttons other than submit.
+func (cr *Crawler) fieldValue(field FormField) (string, bool) {
+	if v, ok := cr.cfg.FormValues[field.Name]; ok {
+		return v, true
+	}
+	for name, v := range cr.cfg.FormValues {
+		if strings.EqualFold(name, field.Name) {
+			return v, true
+		}
+	}
+	switch field.Type {
+	case "file", "button", "reset", "image":
+		return "", false
+	case "hidden", "submit", "select":
+		return field.Value, true
+	}
+	if field.Value != "" {
+		return field.Value, true
+	}
+	if field.Type == "text" && strings.Contains(strings.ToLower(field.Name), "mail") {
+		return defaultFieldValues["email"], true
+	}
+	if v, ok := defaultFieldValues[field.Type]; ok {
+		return v, true
+	}
+	return defaultFieldValues["text"], true
+}
+
+// submitForm fills f with dummy values and submits it, so the response is
+// crawled like any other page. GET forms are always submitted, POST forms
+// only with Config.AllowPost. Each form is submitted once per seed.
+func (cr *Crawler) submitForm(s *seedCrawl, e *colly.HTMLElement, f *Form) {
+	values := url.Values{}
+	seenRadio := make(map[string]bool)
+	for _, field := range f.Fields {
+		if field.Type == "radio" {
+			// Only one button of a group can be submitted
+			if seenRadio[field.Name] {
+				continue
+			}
+			seenRadio[field.Name] = true
+		}
+		if v, ok := cr.fieldValue(field); ok {
+			values.Add(field.Name, v)
+		}
+	}
+
+	// Unknown methods are submitted as GET, as browsers do
+	method := f.Method
+	if method != "POST" {
+		method = "GET"
+	}
+	if method == "POST" && !cr.cfg.AllowPost {
+		return
+	}
+	if _, done := s.submitted.LoadOrStore(method+" "+f.Action+"?"+values.Encode(), true); done {
+		return
+	}
+
+	if method == "GET" {
+		u, err := url.Parse(f.Action)
+		if err != nil {
+			return
+		}
+		u.RawQuery = values.Encode()
+		u.Fragment = ""
+		e.Request.Visit(u.String())
+		return
+	}
+	if f.Enctype == "multipart/form-data" {
+		cr.postMultipart(e.Request, f.Action, values)
+		return
+	}
+	data := make(map[string]string, len(values))
+	for name := range values {
+		data[name] = values.Get(name)
+	}
+	e.Request.Post(f.Action, data)
+}
+
+// postMultipart posts values as multipart/form-data to action, one level
+// deeper than req. colly's PostMultipart can't be used: its revisit check
+// drains the request body, so the form is sent empty.
+func (cr *Crawler) postMultipart(req *colly.Request, action string, values url.Values) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range values[name] {
+			w.WriteField(name, v)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return
+	}
+
+	post, err := req.New("POST", action, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return
+	}
+	post.Depth = req.Depth + 1
+	post.Headers.Set("Content-Type", w.FormDataContentType())
+	post.Headers.Set("User-Agent", cr.cfg.UserAgent)
+	post.Do()
+}
@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestSubmitForms(t *testing.T) {
+	const forms = `
+		<form action="/search">
+			<input name="q">
+			<input name="contact_mail">
+			<input type="radio" name="r" value="a">
+			<input type="radio" name="r" value="b">
+			<input type="submit" name="go" value="Search">
+		</form>
+		<form action="/login" method="post">
+			<input type="hidden" name="csrf" value="x">
+			<input name="user">
+			<input type="password" name="password">
+		</form>
+		<form action="/upload" method="post" enctype="multipart/form-data">
+			<input name="title">
+			<input type="file" name="doc">
+			<input type="reset" name="clear" value="Clear">
+		</form>`
+
+	tests := []struct {
+		name      string
+		submit    bool
+		allowPost bool
+		want      []string
+	}{
+		{"disabled", false, true, nil},
+		{"get only", true, false, []string{
+			"GET /search contact_mail=test%40example.com&go=Search&q=test&r=a",
+		}},
+		{"allow post", true, true, []string{
+			"GET /search contact_mail=test%40example.com&go=Search&q=test&r=a",
+			"POST /login csrf=x&password=Password123%21&user=admin",
+			"POST /upload title=test",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var submitted []string
+			record := func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+					t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+				}
+				mu.Lock()
+				submitted = append(submitted, r.Method+" "+r.URL.Path+" "+r.Form.Encode())
+				mu.Unlock()
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `<html><body><a href="/other">other</a>`+forms+`</body></html>`)
+			})
+			// The same forms on a second page are not submitted again
+			mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `<html><body>`+forms+`</body></html>`)
+			})
+			mux.HandleFunc("/search", record)
+			mux.HandleFunc("/login", record)
+			mux.HandleFunc("/upload", record)
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			cfg := testConfig()
+			cfg.Depth = 3
+			cfg.SubmitForms = tt.submit
+			cfg.AllowPost = tt.allowPost
+			cfg.FormValues = map[string]string{"USER": "admin"}
+			crawl(t, cfg, server.URL+"/")
+
+			sort.Strings(submitted)
+			if !reflect.DeepEqual(submitted, tt.want) {
+				t.Errorf("submitted %q, want %q", submitted, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextTransport binds every request to ctx, so cancelling ctx aborts
+// requests that are already in flight. It also applies configured Host
+// headers, see applyHostHeader.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.WithContext(t.ctx)
+	if req.Header.Get("Host") != "" {
+		// The request belongs to the caller, so its header is copied
+		req.Header = req.Header.Clone()
+		applyHostHeader(req)
+	}
+	return t.base.RoundTrip(req)
+}
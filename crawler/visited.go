@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/gocolly/colly/v2/storage"
+	"github.com/xerocorps/paxkk/dedup"
+)
+
+// visitedStorage is the colly storage of a seed's collector when
+// Config.Visited is set. Visited request IDs go to the shared store,
+// prefixed with the seed so each seed keeps its own visited set; cookies
+// stay in memory as with colly's default storage.
+type visitedStorage struct {
+	*storage.InMemoryStorage
+	store  dedup.Store
+	prefix string
+
+	mu      sync.Mutex
+	revisit map[uint64]bool
+}
+
+func newVisitedStorage(store dedup.Store, seed string) *visitedStorage {
+	return &visitedStorage{
+		InMemoryStorage: &storage.InMemoryStorage{},
+		store:           store,
+		prefix:          seed + "\x00",
+	}
+}
+
+// Visited implements storage.Storage.
+func (v *visitedStorage) Visited(requestID uint64) error {
+	_, err := v.store.Add(v.prefix + strconv.FormatUint(requestID, 16))
+	return err
+}
+
+// IsVisited implements storage.Storage.
+func (v *visitedStorage) IsVisited(requestID uint64) (bool, error) {
+	v.mu.Lock()
+	if v.revisit[requestID] {
+		delete(v.revisit, requestID)
+		v.mu.Unlock()
+		return false, nil
+	}
+	v.mu.Unlock()
+	return v.store.Has(v.prefix + strconv.FormatUint(requestID, 16))
+}
+
+// allowRevisit lets the request with the given ID through once even though
+// it was visited, for resumed frontier requests.
+func (v *visitedStorage) allowRevisit(requestID uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.revisit == nil {
+		v.revisit = make(map[uint64]bool)
+	}
+	v.revisit[requestID] = true
+}
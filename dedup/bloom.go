@@ -0,0 +1,132 @@
+package dedup
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+const (
+	// bloomInitialCapacity is the number of keys the first filter holds.
+	bloomInitialCapacity = 1 << 20
+	// bloomTightening is the ratio between the false positive rates of
+	// consecutive filters, so the rates sum to less than the target.
+	bloomTightening = 0.5
+)
+
+// Bloom is a scalable Bloom filter: a series of filters, each twice the
+// capacity of the previous one with a tighter false positive rate, so
+// memory grows with the number of keys while the overall false positive
+// rate stays below the target. A false positive makes a new key look
+// already seen.
+type Bloom struct {
+	mu      sync.Mutex
+	fpRate  float64
+	filters []*bloomFilter
+}
+
+// bloomFilter is one fixed size filter of a Bloom.
+type bloomFilter struct {
+	bits     []uint64
+	m        uint64
+	k        int
+	capacity int
+	count    int
+}
+
+// NewBloom returns an empty Bloom with the given overall false positive
+// rate, between 0 and 1.
+func NewBloom(fpRate float64) (*Bloom, error) {
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("bloom false positive rate must be between 0 and 1")
+	}
+	b := &Bloom{fpRate: fpRate}
+	b.grow()
+	return b, nil
+}
+
+// grow appends a new filter. The caller holds mu.
+func (b *Bloom) grow() {
+	capacity := bloomInitialCapacity << len(b.filters)
+	p := b.fpRate * (1 - bloomTightening) * math.Pow(bloomTightening, float64(len(b.filters)))
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := int(math.Ceil(float64(m) / float64(capacity) * math.Ln2))
+	b.filters = append(b.filters, &bloomFilter{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: capacity,
+	})
+}
+
+// bloomHashes returns the two base hashes of key for double hashing.
+func bloomHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	// splitmix64 finalizer for an independent second hash
+	h2 := h1 + 0x9e3779b97f4a7c15
+	h2 = (h2 ^ h2>>30) * 0xbf58476d1ce4e5b9
+	h2 = (h2 ^ h2>>27) * 0x94d049bb133111eb
+	h2 ^= h2 >> 31
+	return h1, h2 | 1
+}
+
+func (f *bloomFilter) has(h1, h2 uint64) bool {
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *bloomFilter) add(h1, h2 uint64) {
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// has reports whether any filter may hold the key. The caller holds mu.
+func (b *Bloom) has(h1, h2 uint64) bool {
+	for _, f := range b.filters {
+		if f.has(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add implements Store.
+func (b *Bloom) Add(key string) (bool, error) {
+	h1, h2 := bloomHashes(key)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.has(h1, h2) {
+		return false, nil
+	}
+	last := b.filters[len(b.filters)-1]
+	if last.count >= last.capacity {
+		b.grow()
+		last = b.filters[len(b.filters)-1]
+	}
+	last.add(h1, h2)
+	return true, nil
+}
+
+// Has implements Store.
+func (b *Bloom) Has(key string) (bool, error) {
+	h1, h2 := bloomHashes(key)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.has(h1, h2), nil
+}
+
+// Close implements Store.
+func (b *Bloom) Close() error {
+	return nil
+}
@@ -0,0 +1,90 @@
+package dedup
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewBloomRate(t *testing.T) {
+	for _, rate := range []float64{0, -0.1, 1, 2} {
+		if _, err := NewBloom(rate); err == nil {
+			t.Errorf("NewBloom(%v) accepted an invalid rate", rate)
+		}
+	}
+}
+
+func TestBloomAdd(t *testing.T) {
+	b, err := NewBloom(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint("https://a.com/", i)
+		if added, _ := b.Add(key); !added {
+			// A false positive is possible but shouldn't happen this early
+			t.Fatalf("Add(%q) = false for a new key", key)
+		}
+		if added, _ := b.Add(key); added {
+			t.Fatalf("second Add(%q) = true", key)
+		}
+		if found, _ := b.Has(key); !found {
+			t.Fatalf("Has(%q) = false", key)
+		}
+	}
+}
+
+func TestBloomGrow(t *testing.T) {
+	b, err := NewBloom(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := b.filters[0]
+	b.Add("first")
+	// Pretend the first filter is full
+	first.count = first.capacity
+	b.Add("second")
+	if len(b.filters) != 2 {
+		t.Fatalf("%d filters, want 2", len(b.filters))
+	}
+	next := b.filters[1]
+	if next.capacity != 2*first.capacity {
+		t.Errorf("second filter holds %d keys, want %d", next.capacity, 2*first.capacity)
+	}
+	if next.m <= first.m || next.count != 1 {
+		t.Errorf("second filter has %d bits and %d keys, want more than %d bits and 1 key", next.m, next.count, first.m)
+	}
+	for _, key := range []string{"first", "second"} {
+		if found, _ := b.Has(key); !found {
+			t.Errorf("Has(%q) = false after growing", key)
+		}
+	}
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("fills more than one filter")
+	}
+	const target = 0.01
+	b, err := NewBloom(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fill the first filter and half of the second
+	n := bloomInitialCapacity * 2
+	for i := 0; i < n; i++ {
+		b.Add(fmt.Sprint("in-", i))
+	}
+	if len(b.filters) != 2 {
+		t.Fatalf("%d filters after %d keys, want 2", len(b.filters), n)
+	}
+	const probes = 200000
+	falsePositives := 0
+	for i := 0; i < probes; i++ {
+		if found, _ := b.Has(fmt.Sprint("out-", i)); found {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / probes; rate > target {
+		t.Errorf("false positive rate %.4f, want at most %.4f", rate, target)
+	}
+}
@@ -0,0 +1,78 @@
+// Package dedup provides the sets paxkk uses to remember which URLs it
+// has already seen, for -u and for the crawl's visited requests. Memory is
+// exact but grows with the crawl; Bloom and Disk keep RAM bounded on
+// multi-million URL runs.
+package dedup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store is a set of keys. Implementations are safe for concurrent use.
+type Store interface {
+	// Add records key and reports whether it was not in the set before.
+	Add(key string) (bool, error)
+	// Has reports whether key is in the set.
+	Has(key string) (bool, error)
+	Close() error
+}
+
+// Backends are the names accepted by Open.
+var Backends = []string{"memory", "bloom", "disk"}
+
+// Open returns a new store of the named backend. fpRate is the false
+// positive rate of the bloom backend; the disk backend keeps its table in
+// dir/name.db.
+func Open(backend, dir, name string, fpRate float64) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemory(), nil
+	case "bloom":
+		return NewBloom(fpRate)
+	case "disk":
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		return OpenDisk(filepath.Join(dir, name+".db"))
+	}
+	return nil, fmt.Errorf("unknown dedup backend %q (want %s)", backend, strings.Join(Backends, ", "))
+}
+
+// Memory is an exact in-memory Store.
+type Memory struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// NewMemory returns an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{keys: make(map[string]struct{})}
+}
+
+// Add implements Store.
+func (m *Memory) Add(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key]; ok {
+		return false, nil
+	}
+	m.keys[key] = struct{}{}
+	return true, nil
+}
+
+// Has implements Store.
+func (m *Memory) Has(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.keys[key]
+	return ok, nil
+}
+
+// Close implements Store.
+func (m *Memory) Close() error {
+	return nil
+}
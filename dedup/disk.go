@@ -0,0 +1,229 @@
+package dedup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	diskMagic = "PXKDEDUP"
+	// diskHeaderSize is magic, slot count and key count.
+	diskHeaderSize = 24
+	// diskSlotSize is the size of a stored key hash.
+	diskSlotSize = 16
+	// diskInitialSlots is the table size of a new store.
+	diskInitialSlots = 1 << 16
+	// diskProbeSlots is the number of slots read at once while probing.
+	diskProbeSlots = 64
+	// diskSyncEvery is how often, in added keys, the header count is
+	// written back.
+	diskSyncEvery = 1024
+)
+
+// Disk is a Store kept in an on-disk hash table of 128-bit key hashes with
+// linear probing, so RAM use stays constant whatever the number of keys.
+// The table doubles when it is half full. Reopening the file resumes the
+// set.
+type Disk struct {
+	mu    sync.Mutex
+	path  string
+	f     *os.File
+	slots uint64
+	count uint64
+	dirty int
+}
+
+// OpenDisk opens the store at path, creating it if needed.
+func OpenDisk(path string) (*Disk, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	d := &Disk{path: path, f: f}
+	header := make([]byte, diskHeaderSize)
+	switch _, err := f.ReadAt(header, 0); {
+	case err == io.EOF:
+		err = d.init(diskInitialSlots)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	case err != nil:
+		f.Close()
+		return nil, err
+	case string(header[:8]) != diskMagic:
+		f.Close()
+		return nil, fmt.Errorf("%s: not a dedup store", path)
+	default:
+		d.slots = binary.LittleEndian.Uint64(header[8:])
+		d.count = binary.LittleEndian.Uint64(header[16:])
+	}
+	return d, nil
+}
+
+// init sizes an empty table file.
+func (d *Disk) init(slots uint64) error {
+	d.slots, d.count = slots, 0
+	if err := d.f.Truncate(diskHeaderSize + int64(slots)*diskSlotSize); err != nil {
+		return err
+	}
+	return d.writeHeader()
+}
+
+func (d *Disk) writeHeader() error {
+	header := make([]byte, diskHeaderSize)
+	copy(header, diskMagic)
+	binary.LittleEndian.PutUint64(header[8:], d.slots)
+	binary.LittleEndian.PutUint64(header[16:], d.count)
+	_, err := d.f.WriteAt(header, 0)
+	d.dirty = 0
+	return err
+}
+
+// diskHash returns the stored form of key. The zero hash marks empty
+// slots, so it is never returned.
+func diskHash(key string) []byte {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	sum := h.Sum(nil)
+	if bytes.Equal(sum, make([]byte, diskSlotSize)) {
+		sum[diskSlotSize-1] = 1
+	}
+	return sum
+}
+
+// find probes for hash, returning whether it is stored and otherwise the
+// empty slot where it belongs. The caller holds mu.
+func (d *Disk) find(hash []byte) (bool, uint64, error) {
+	empty := make([]byte, diskSlotSize)
+	buf := make([]byte, diskProbeSlots*diskSlotSize)
+	slot := binary.LittleEndian.Uint64(hash) % d.slots
+	for probed := uint64(0); probed < d.slots; {
+		n := uint64(diskProbeSlots)
+		if slot+n > d.slots {
+			n = d.slots - slot
+		}
+		chunk := buf[:n*diskSlotSize]
+		if _, err := d.f.ReadAt(chunk, diskHeaderSize+int64(slot)*diskSlotSize); err != nil {
+			return false, 0, err
+		}
+		for i := uint64(0); i < n; i++ {
+			s := chunk[i*diskSlotSize : (i+1)*diskSlotSize]
+			if bytes.Equal(s, hash) {
+				return true, 0, nil
+			}
+			if bytes.Equal(s, empty) {
+				return false, slot + i, nil
+			}
+		}
+		probed += n
+		slot = (slot + n) % d.slots
+	}
+	return false, 0, errors.New("dedup store is full")
+}
+
+// insert stores hash, growing the table first if needed. The caller holds
+// mu.
+func (d *Disk) insert(hash []byte) (bool, error) {
+	found, slot, err := d.find(hash)
+	if err != nil || found {
+		return false, err
+	}
+	if (d.count+1)*2 > d.slots {
+		if err := d.grow(); err != nil {
+			return false, err
+		}
+		if _, slot, err = d.find(hash); err != nil {
+			return false, err
+		}
+	}
+	if _, err := d.f.WriteAt(hash, diskHeaderSize+int64(slot)*diskSlotSize); err != nil {
+		return false, err
+	}
+	d.count++
+	if d.dirty++; d.dirty >= diskSyncEvery {
+		return true, d.writeHeader()
+	}
+	return true, nil
+}
+
+// grow rehashes the table into a file twice the size and replaces the
+// current one with it. The caller holds mu.
+func (d *Disk) grow() error {
+	tmpPath := d.path + ".grow"
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	next := &Disk{path: d.path, f: f}
+	if err := next.init(d.slots * 2); err != nil {
+		f.Close()
+		return err
+	}
+	empty := make([]byte, diskSlotSize)
+	buf := make([]byte, diskProbeSlots*diskSlotSize)
+	for slot := uint64(0); slot < d.slots; slot += diskProbeSlots {
+		n := uint64(diskProbeSlots)
+		if slot+n > d.slots {
+			n = d.slots - slot
+		}
+		chunk := buf[:n*diskSlotSize]
+		if _, err := d.f.ReadAt(chunk, diskHeaderSize+int64(slot)*diskSlotSize); err != nil {
+			f.Close()
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			s := chunk[i*diskSlotSize : (i+1)*diskSlotSize]
+			if bytes.Equal(s, empty) {
+				continue
+			}
+			if _, err := next.insert(append([]byte(nil), s...)); err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	if err := next.writeHeader(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, d.path); err != nil {
+		f.Close()
+		return err
+	}
+	d.f.Close()
+	d.f, d.slots, d.count, d.dirty = f, next.slots, next.count, 0
+	return nil
+}
+
+// Add implements Store.
+func (d *Disk) Add(key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.insert(diskHash(key))
+}
+
+// Has implements Store.
+func (d *Disk) Has(key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	found, _, err := d.find(diskHash(key))
+	return found, err
+}
+
+// Close writes the header and closes the file.
+func (d *Disk) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.writeHeader(); err != nil {
+		d.f.Close()
+		return err
+	}
+	return d.f.Close()
+}
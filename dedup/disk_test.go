@@ -0,0 +1,160 @@
+package dedup
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDisk(t *testing.T) (*Disk, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "seen.db")
+	d, err := OpenDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, path
+}
+
+func TestDiskAdd(t *testing.T) {
+	d, _ := openTestDisk(t)
+	defer d.Close()
+	for _, key := range []string{"https://a.com/", "https://a.com/x", ""} {
+		if added, err := d.Add(key); err != nil || !added {
+			t.Fatalf("Add(%q) = %v, %v, want true", key, added, err)
+		}
+		if added, err := d.Add(key); err != nil || added {
+			t.Fatalf("second Add(%q) = %v, %v, want false", key, added, err)
+		}
+		if found, err := d.Has(key); err != nil || !found {
+			t.Fatalf("Has(%q) = %v, %v, want true", key, found, err)
+		}
+	}
+	if found, _ := d.Has("https://b.com/"); found {
+		t.Error("Has reports a key that was never added")
+	}
+}
+
+func TestDiskGrow(t *testing.T) {
+	d, path := openTestDisk(t)
+	defer d.Close()
+	if err := d.init(8); err != nil {
+		t.Fatal(err)
+	}
+	const n = 1000
+	for i := 0; i < n; i++ {
+		if added, err := d.Add(fmt.Sprint("key", i)); err != nil || !added {
+			t.Fatalf("Add(key%d) = %v, %v", i, added, err)
+		}
+	}
+	if d.count != n {
+		t.Errorf("count = %d, want %d", d.count, n)
+	}
+	if d.count*2 > d.slots {
+		t.Errorf("%d slots for %d keys, want at most half full", d.slots, d.count)
+	}
+	for i := 0; i < n; i++ {
+		if found, err := d.Has(fmt.Sprint("key", i)); err != nil || !found {
+			t.Fatalf("Has(key%d) = %v, %v after growing", i, found, err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(diskHeaderSize + d.slots*diskSlotSize); info.Size() != want {
+		t.Errorf("file size = %d, want %d", info.Size(), want)
+	}
+	if _, err := os.Stat(path + ".grow"); !os.IsNotExist(err) {
+		t.Errorf("temporary table left behind: %v", err)
+	}
+}
+
+func TestDiskProbeWraparound(t *testing.T) {
+	d, _ := openTestDisk(t)
+	defer d.Close()
+	if err := d.init(8); err != nil {
+		t.Fatal(err)
+	}
+	// Three hashes that all belong in the last slot
+	hashes := make([][]byte, 3)
+	for i := range hashes {
+		hashes[i] = make([]byte, diskSlotSize)
+		binary.LittleEndian.PutUint64(hashes[i], 7)
+		hashes[i][diskSlotSize-1] = byte(i + 1)
+		if added, err := d.insert(hashes[i]); err != nil || !added {
+			t.Fatalf("insert %d = %v, %v", i, added, err)
+		}
+	}
+	if d.slots != 8 {
+		t.Fatalf("table grew to %d slots", d.slots)
+	}
+	for i, hash := range hashes {
+		found, _, err := d.find(hash)
+		if err != nil || !found {
+			t.Errorf("find %d = %v, %v, want found", i, found, err)
+		}
+	}
+	// The collisions continue at the start of the table
+	slot := make([]byte, diskSlotSize)
+	if _, err := d.f.ReadAt(slot, diskHeaderSize); err != nil {
+		t.Fatal(err)
+	}
+	if string(slot) != string(hashes[1]) {
+		t.Errorf("slot 0 = %x, want %x", slot, hashes[1])
+	}
+	missing := make([]byte, diskSlotSize)
+	binary.LittleEndian.PutUint64(missing, 7)
+	missing[diskSlotSize-1] = 9
+	found, empty, err := d.find(missing)
+	if err != nil || found || empty != 2 {
+		t.Errorf("find of a missing hash = %v, slot %d, %v, want slot 2", found, empty, err)
+	}
+}
+
+func TestDiskReopen(t *testing.T) {
+	d, path := openTestDisk(t)
+	if err := d.init(8); err != nil {
+		t.Fatal(err)
+	}
+	const n = 100
+	for i := 0; i < n; i++ {
+		if _, err := d.Add(fmt.Sprint("key", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slots := d.slots
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenDisk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.count != n || d.slots != slots {
+		t.Errorf("reopened with %d keys in %d slots, want %d in %d", d.count, d.slots, n, slots)
+	}
+	for i := 0; i < n; i++ {
+		if added, err := d.Add(fmt.Sprint("key", i)); err != nil || added {
+			t.Fatalf("Add(key%d) after reopening = %v, %v, want false", i, added, err)
+		}
+	}
+	if added, err := d.Add("new"); err != nil || !added {
+		t.Errorf("Add(new) after reopening = %v, %v, want true", added, err)
+	}
+}
+
+func TestOpenDiskRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.db")
+	if err := os.WriteFile(path, []byte("this is not a dedup store"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := OpenDisk(path); err == nil {
+		d.Close()
+		t.Error("OpenDisk accepted a file that is not a dedup store")
+	}
+}
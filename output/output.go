@@ -0,0 +1,166 @@
+// Package output writes crawler results to stdout and files in the formats
+// supported by paxkk.
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xerocorps/paxkk/crawler"
+)
+
+// Format is an output record format.
+type Format string
+
+const (
+	// FormatTxt writes one URL per line, optionally prefixed with its
+	// source and the page it was found on.
+	FormatTxt Format = "txt"
+	// FormatJSONL writes one JSON object per line.
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes comma separated records with a header row.
+	FormatCSV Format = "csv"
+)
+
+// SchemaVersion is the version of the JSON record schema written by
+// FormatJSONL. Version 1 is the original {"Source","URL","Where"} record.
+const SchemaVersion = 2
+
+// csvHeader is the first row of every CSV file.
+var csvHeader = []string{"source", "url", "where", "seed", "depth", "status", "content_type", "tag", "attribute", "source_file", "confidence", "timestamp", "url_status", "in_scope"}
+
+// record is the versioned JSON form of a result.
+type record struct {
+	Version int `json:"version"`
+	crawler.Result
+}
+
+// legacyRecord is the version 1 JSON form of a result.
+type legacyRecord struct {
+	Source string
+	URL    string
+	Where  string
+}
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatTxt, FormatJSONL, FormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (want txt, jsonl or csv)", name)
+}
+
+// Options controls which optional fields are rendered.
+type Options struct {
+	// ShowSource includes the result source (-s).
+	ShowSource bool
+	// ShowWhere includes the page the result was found on (-w).
+	ShowWhere bool
+	// JSONVersion selects the FormatJSONL schema, 0 for SchemaVersion.
+	JSONVersion int
+}
+
+// Sink encodes results in one format onto an underlying writer.
+type Sink struct {
+	format Format
+	opts   Options
+	w      *bufio.Writer
+	csv    *csv.Writer
+}
+
+// NewSink returns a Sink writing format records to w.
+func NewSink(w io.Writer, format Format, opts Options) *Sink {
+	s := &Sink{format: format, opts: opts, w: bufio.NewWriter(w)}
+	if format == FormatCSV {
+		s.csv = csv.NewWriter(s.w)
+	}
+	return s
+}
+
+// Write encodes a single result. Output is buffered until Flush.
+func (s *Sink) Write(res crawler.Result) error {
+	switch s.format {
+	case FormatCSV:
+		if !s.opts.ShowWhere {
+			res.Where = ""
+		}
+		return s.csv.Write([]string{
+			res.Source, res.URL, res.Where, res.Seed,
+			strconv.Itoa(res.Depth), itoaNonZero(res.Status), res.ContentType,
+			res.Tag, res.Attribute, res.SourceFile, formatConfidence(res.Confidence),
+			res.Timestamp.Format(time.RFC3339Nano),
+			itoaNonZero(res.URLStatus), strconv.FormatBool(res.InScope),
+		})
+	default:
+		_, err := s.w.WriteString(Line(res, s.format, s.opts) + "\n")
+		return err
+	}
+}
+
+// Flush writes any buffered output to the underlying writer.
+func (s *Sink) Flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+// Line renders res as a single txt or jsonl line, without the newline.
+func Line(res crawler.Result, format Format, opts Options) string {
+	if format == FormatJSONL {
+		if !opts.ShowWhere {
+			res.Where = ""
+		}
+		var bytes []byte
+		if opts.JSONVersion == 1 {
+			bytes, _ = json.Marshal(legacyRecord{Source: res.Source, URL: res.URL, Where: res.Where})
+		} else {
+			bytes, _ = json.Marshal(record{Version: SchemaVersion, Result: res})
+		}
+		return string(bytes)
+	}
+
+	result := res.URL
+	if opts.ShowSource {
+		result = "[" + res.Source + "] " + result
+	}
+	if opts.ShowWhere {
+		result = "[" + res.Where + "] " + result
+	}
+	return result
+}
+
+// itoaNonZero formats n, or returns "" for zero.
+func itoaNonZero(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// formatConfidence formats a confidence score, or returns "" for zero.
+func formatConfidence(c float64) string {
+	if c == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(c, 'f', -1, 64)
+}
+
+// Header returns the bytes written at the start of an empty file of the
+// given format.
+func Header(format Format) []byte {
+	if format != FormatCSV {
+		return nil
+	}
+	return []byte(strings.Join(csvHeader, ",") + "\n")
+}
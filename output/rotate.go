@@ -0,0 +1,171 @@
+package output
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// File is an append-only output file with optional size based rotation.
+// When a write would grow the file past MaxSize, the file is renamed with a
+// timestamp suffix, optionally gzip compressed, and a fresh file is opened.
+type File struct {
+	path    string
+	maxSize int64
+	gzip    bool
+	header  []byte
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	// limit is the size past which the file is rotated. After a failed
+	// rotation it is raised by maxSize, so the file is kept and rotation is
+	// retried later rather than on every write.
+	limit int64
+	// compressing tracks the rotated files being gzipped.
+	compressing sync.WaitGroup
+}
+
+// OpenFile opens path for appending. maxSize is in bytes, 0 disables
+// rotation. header is written at the start of every new, empty file.
+func OpenFile(path string, maxSize int64, compress bool, header []byte) (*File, error) {
+	f := &File{path: path, maxSize: maxSize, gzip: compress, header: header, limit: maxSize}
+	file, size, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.f, f.size = file, size
+	return f, nil
+}
+
+// open opens f.path for appending, writing the header if it is empty, and
+// returns the file and its size.
+func (f *File) open() (*os.File, int64, error) {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	size := info.Size()
+	if size == 0 && len(f.header) > 0 {
+		n, err := file.Write(f.header)
+		if err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		size += int64(n)
+	}
+	return file, size, nil
+}
+
+// Write implements io.Writer, rotating the file first if needed.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > int64(len(f.header)) && f.size+int64(len(p)) > f.limit {
+		// A failed rotation leaves the current file open. The write goes
+		// on, as an error would stop the buffered sink for good.
+		if err := f.rotate(); err != nil {
+			log.Printf("[rotate] %s: %v", f.path, err)
+			f.limit = f.size + f.maxSize
+		} else {
+			f.limit = f.maxSize
+		}
+	}
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file, once the rotated files are compressed.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.compressing.Wait()
+	return f.f.Close()
+}
+
+// rotate moves the current file aside and opens a new one. It only fails,
+// leaving the current file in use, if the new file can't be opened; once it
+// is, errors closing or compressing the old one are logged. Compression
+// runs in the background so writes aren't held up.
+func (f *File) rotate() error {
+	rotated := f.rotatedName()
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+	file, size, err := f.open()
+	if err != nil {
+		// Put the file back where it was, it is still open either way
+		os.Rename(rotated, f.path)
+		return err
+	}
+	old := f.f
+	f.f, f.size = file, size
+	if err := old.Close(); err != nil {
+		log.Printf("[rotate] %s: %v", rotated, err)
+	}
+	if f.gzip {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			if err := gzipFile(rotated); err != nil {
+				log.Printf("[rotate] %s: %v", rotated, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// rotatedName returns a free name for the current file with a timestamp
+// suffix, numbered if files were rotated within the same millisecond.
+func (f *File) rotatedName() string {
+	base := fmt.Sprintf("%s.%s", f.path, time.Now().Format("20060102-150405.000"))
+	name := base
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// gzipFile compresses path to path.gz and removes the original.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,173 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// rotated returns the contents of the files rotated away from path, oldest
+// first, decompressing .gz files.
+func rotated(t *testing.T, path string) []string {
+	t.Helper()
+	names, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	var contents []string
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = file
+		if strings.HasSuffix(name, ".gz") {
+			zr, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			r = zr
+		}
+		data, err := io.ReadAll(r)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		contents = append(contents, string(data))
+	}
+	return contents
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileRotate(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxSize  int64
+		gzip     bool
+		header   string
+		lines    int
+		rotated  []string
+		current  string
+		gzSuffix bool
+	}{
+		{
+			name:    "no rotation",
+			maxSize: 0,
+			lines:   4,
+			current: "line0\nline1\nline2\nline3\n",
+		},
+		{
+			name:    "size threshold",
+			maxSize: 12,
+			lines:   5,
+			rotated: []string{"line0\nline1\n", "line2\nline3\n"},
+			current: "line4\n",
+		},
+		{
+			name:    "header in every file",
+			maxSize: 16,
+			header:  "url\n",
+			lines:   3,
+			rotated: []string{"url\nline0\nline1\n"},
+			current: "url\nline2\n",
+		},
+		{
+			name:     "gzip",
+			maxSize:  12,
+			gzip:     true,
+			lines:    3,
+			rotated:  []string{"line0\nline1\n"},
+			current:  "line2\n",
+			gzSuffix: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.txt")
+			f, err := OpenFile(path, tt.maxSize, tt.gzip, []byte(tt.header))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.lines; i++ {
+				if _, err := f.Write([]byte("line" + string(rune('0'+i)) + "\n")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := rotated(t, path)
+			if strings.Join(got, "|") != strings.Join(tt.rotated, "|") {
+				t.Errorf("rotated files = %q, want %q", got, tt.rotated)
+			}
+			if current := readFile(t, path); current != tt.current {
+				t.Errorf("current file = %q, want %q", current, tt.current)
+			}
+			names, _ := filepath.Glob(path + ".*")
+			for _, name := range names {
+				if strings.HasSuffix(name, ".gz") != tt.gzSuffix {
+					t.Errorf("rotated file %s, want gzip %v", name, tt.gzSuffix)
+				}
+			}
+		})
+	}
+}
+
+func TestFileReopenKeepsHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	for i := 0; i < 2; i++ {
+		f, err := OpenFile(path, 0, false, []byte("url\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("a\n"))
+		f.Close()
+	}
+	if got := readFile(t, path); got != "url\na\na\n" {
+		t.Errorf("file = %q, want the header once", got)
+	}
+}
+
+func TestFileRotateRenameFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	f, err := OpenFile(path, 12, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("line0\nline1\n"))
+	current := f.f
+	// The rename fails once the file is gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if n, err := f.Write([]byte("more\n")); err != nil || n != 5 {
+			t.Fatalf("Write after a failed rotation = %d, %v", n, err)
+		}
+	}
+	if f.f != current {
+		t.Error("the file in use was replaced although rotation failed")
+	}
+	info, err := f.f.Stat()
+	if err != nil {
+		t.Fatalf("file in use was closed: %v", err)
+	}
+	if info.Size() != 12+3*5 {
+		t.Errorf("file in use holds %d bytes, want %d", info.Size(), 12+3*5)
+	}
+}